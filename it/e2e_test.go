@@ -59,7 +59,10 @@ func (s *e2eTestSuite) SetupSuite() {
 	}
 
 	// Create collector with cert and conn metrics enabled
-	cl := strongswan.NewCollector(viciClientFn, s.enableCertMetrics, s.enableConnMetrics)
+	cl := strongswan.NewCollector(viciClientFn, strongswan.Config{
+		CertMetricsEnabled: s.enableCertMetrics,
+		ConnMetricsEnabled: s.enableConnMetrics,
+	})
 
 	// Setup healthcheck
 	checkers := make([]healthcheck.Option, 0)
@@ -121,6 +124,10 @@ func (s *e2eTestSuite) Test_EndToEnd_Metrics() {
 	s.Contains(metricsBody, `# TYPE strongswan_ike_count gauge`)
 	s.Contains(metricsBody, `strongswan_ike_count 1`)
 
+	// Check for daemon info metrics
+	s.Contains(metricsBody, `# HELP strongswan_daemon_info Name, version and host system of the daemon`)
+	s.Contains(metricsBody, `strongswan_daemon_info{daemon="charon"`)
+
 	// Check for IKE children size metrics
 	s.Contains(metricsBody, `# HELP strongswan_ike_children_size Count of children of this IKE`)
 	s.Contains(metricsBody, `# TYPE strongswan_ike_children_size gauge`)
@@ -177,6 +184,11 @@ func (s *e2eTestSuite) Test_EndToEnd_Metrics() {
 	s.Contains(metricsBody, `# TYPE strongswan_ike_status gauge`)
 	s.Contains(metricsBody, fmt.Sprintf(`strongswan_ike_status{ike_id="1",ike_name="%s"} 1`, s.ikeName))
 
+	s.Contains(metricsBody, `# HELP strongswan_ike_state State of this IKE, 1 for the current state`)
+	s.Contains(metricsBody, `# TYPE strongswan_ike_state gauge`)
+	s.Contains(metricsBody, fmt.Sprintf(`strongswan_ike_state{ike_id="1",ike_name="%s",state="ESTABLISHED"} 1`, s.ikeName))
+	s.Contains(metricsBody, fmt.Sprintf(`strongswan_ike_state{ike_id="1",ike_name="%s",state="CONNECTING"} 0`, s.ikeName))
+
 	s.Contains(metricsBody, `# HELP strongswan_ike_version Version of this IKE`)
 	s.Contains(metricsBody, `# TYPE strongswan_ike_version gauge`)
 	s.Contains(metricsBody, fmt.Sprintf(`strongswan_ike_version{ike_id="1",ike_name="%s"} 2`, s.ikeName))
@@ -236,6 +248,11 @@ func (s *e2eTestSuite) Test_EndToEnd_Metrics() {
 	s.Contains(metricsBody, `# TYPE strongswan_sa_rekey_seconds gauge`)
 	s.Contains(metricsBody, fmt.Sprintf(`strongswan_sa_rekey_seconds{child_id="1",child_name="net",ike_id="1",ike_name="%s"}`, s.ikeName))
 
+	// Check for SA state metrics
+	s.Contains(metricsBody, `# HELP strongswan_sa_state State of this child sa, 1 for the current state`)
+	s.Contains(metricsBody, `# TYPE strongswan_sa_state gauge`)
+	s.Contains(metricsBody, fmt.Sprintf(`strongswan_sa_state{child_id="1",child_name="net",ike_id="1",ike_name="%s",state="INSTALLED"} 1`, s.ikeName))
+
 	// Check for SA status metrics
 	s.Contains(metricsBody, `# HELP strongswan_sa_status Status of this child sa`)
 	s.Contains(metricsBody, `# TYPE strongswan_sa_status gauge`)
@@ -248,7 +265,7 @@ func (s *e2eTestSuite) Test_EndToEnd_CertMetrics() {
 	// Check for certificate count metric
 	s.Contains(metricsBody, `# HELP strongswan_cert_count Number of X509 certificates`)
 	s.Contains(metricsBody, `# TYPE strongswan_cert_count gauge`)
-	s.Contains(metricsBody, `strongswan_cert_count 3`)
+	s.Contains(metricsBody, `strongswan_cert_count 4`)
 
 	// Check for certificate expiration metrics
 	s.Contains(metricsBody, `# HELP strongswan_cert_expire_secs Seconds until the X509 certificate expires`)
@@ -275,6 +292,9 @@ func (s *e2eTestSuite) Test_EndToEnd_CertMetrics() {
 
 	// Check CA certificate validity
 	s.Contains(metricsBody, `strongswan_cert_valid{not_after="2034-03-20T15:01:04Z",not_before="2024-03-20T15:01:04Z",serial_number="63:68:4d:00:11:20:7d:dc",subject="CN=Cyber Root CA,O=Cyber,C=CH"} 1`)
+
+	// Check the CA certificate of the authority, loaded with load-authorities only, is listed
+	s.Contains(metricsBody, `strongswan_cert_valid{not_after="2035-01-01T00:00:00Z",not_before="2025-01-01T00:00:00Z",serial_number="04:00",subject="CN=Revocation CA,O=Revocation,C=CH"} 1`)
 }
 
 func (s *e2eTestSuite) Test_EndToEnd_ConnMetrics() {
@@ -8,8 +8,11 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
+	// The runtime image has no time zone database for --charon-timezone.
+	_ "time/tzdata"
 
 	"github.com/etherlabsio/healthcheck/v2"
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,21 +24,54 @@ import (
 )
 
 const (
-	gracefulShutdownWait = time.Second * 60
-	requestTimeout       = time.Second * 30
-	readHeaderTimeout    = time.Second * 30
-	defaultServerPort    = 8079
-	defaultServerHost    = ""
+	gracefulShutdownWait     = time.Second * 60
+	requestTimeout           = time.Second * 30
+	readHeaderTimeout        = time.Second * 30
+	defaultServerPort        = 8079
+	defaultServerHost        = ""
+	defaultMinBackoff        = time.Second
+	defaultMaxBackoff        = time.Minute
+	defaultViciTimeout       = time.Second * 10
+	defaultSaCacheSync       = time.Minute * 5
+	defaultRevocationTTL     = time.Minute * 15
+	defaultRevocationTimeout = time.Second * 5
 )
 
 var (
-	serverHost         = flag.String("server-host", defaultServerHost, "Server bind host (default all interfaces)")
-	serverPort         = flag.Uint("server-port", defaultServerPort, "Server port")
-	logLevel           = flag.String("log-level", "info", "Log level")
-	viciNetwork        = flag.String("vici-network", "tcp", "Vici network (tcp, udp or unix)")
-	viciAddr           = flag.String("vici-address", "localhost:4502", "Vici host and port or unix socket path")
-	certMetricsEnabled = flag.Bool("enable-cert-metrics", false, "Enable X509 certificate metrics")
-	connMetricsEnabled = flag.Bool("enable-conn-metrics", false, "Enable connection configuration metrics")
+	serverHost          = flag.String("server-host", defaultServerHost, "Server bind host (default all interfaces)")
+	serverPort          = flag.Uint("server-port", defaultServerPort, "Server port")
+	logLevel            = flag.String("log-level", "info", "Log level")
+	viciNetwork         = flag.String("vici-network", "tcp", "Vici network (tcp, udp or unix)")
+	viciAddr            = flag.String("vici-address", "localhost:4502", "Vici host and port or unix socket path")
+	viciMinBackoff      = flag.Duration("vici-reconnect-min-backoff", defaultMinBackoff, "Initial delay between failed Vici connection attempts")
+	viciMaxBackoff      = flag.Duration("vici-reconnect-max-backoff", defaultMaxBackoff, "Maximum delay between failed Vici connection attempts")
+	viciTimeout         = flag.Duration("vici-request-timeout", defaultViciTimeout, "Timeout of a Vici request, the session is re-established after a timeout")
+	certMetricsEnabled  = flag.Bool("enable-cert-metrics", false, "Enable X509 certificate metrics")
+	certLabels          = flag.String("cert-labels", "", "Comma separated labels identifying a certificate on the validity metrics (default labels of the metrics schema)")
+	authoritiesEnabled  = flag.Bool("enable-authority-metrics", false, "Enable certification authority metrics")
+	credsEnabled        = flag.Bool("enable-cred-metrics", false, "Enable loaded private key and shared secret metrics")
+	revocationEnabled   = flag.Bool("enable-revocation-metrics", false, "Enable CRL and OCSP revocation checks of the loaded certificates")
+	revocationTimeout   = flag.Duration("revocation-timeout", defaultRevocationTimeout, "Timeout of a CRL or OCSP request")
+	revocationCacheTTL  = flag.Duration("revocation-cache-ttl", defaultRevocationTTL, "Maximum time fetched CRLs and OCSP responses are cached")
+	revocationScope     = flag.String("revocation-scope", string(strongswan.RevocationScopeLocal), "Certificates of which the revocation state is checked, local (with private key and CA certificates) or all")
+	swanctlDir          = flag.String("swanctl-dir", "", "swanctl directory scanned for certificate and key files not loaded into charon, e.g. /etc/swanctl (default disabled)")
+	connMetricsEnabled  = flag.Bool("enable-conn-metrics", false, "Enable connection configuration metrics")
+	connCertsEnabled    = flag.Bool("enable-conn-cert-metrics", false, "Enable expiry metrics of the certificates used by the loaded connections")
+	connStatusEnabled   = flag.Bool("enable-conn-status-metrics", false, "Enable up/down status metrics of the loaded connections")
+	statsEnabled        = flag.Bool("enable-stats-metrics", false, "Enable charon daemon statistics metrics")
+	charonTimeZone      = flag.String("charon-timezone", "", "Time zone of the times printed by charon, e.g. Europe/Zurich (default the time zone of the exporter)")
+	countersEnabled     = flag.Bool("enable-counter-metrics", false, "Enable IKE counters of the counters plugin")
+	algorithmsEnabled   = flag.Bool("enable-algorithm-metrics", false, "Enable supported algorithm metrics")
+	poolsEnabled        = flag.Bool("enable-pool-metrics", false, "Enable virtual IP pool metrics")
+	poolLeasesEnabled   = flag.Bool("enable-pool-lease-metrics", false, "Enable the per lease info metric of the virtual IP pools")
+	policiesEnabled     = flag.Bool("enable-policy-metrics", false, "Enable trap and shunt policy metrics")
+	cryptoPolicy        = flag.String("crypto-policy", "", "Crypto policy preset (bsi-tr-02102, nist-sp-800-77) or JSON policy file the SAs and certificates are evaluated against (default disabled)")
+	ikeInfoLabels       = flag.String("ike-info-labels", strings.Join(strongswan.DefaultIkeInfoLabels, ","), "Comma separated optional labels of the strongswan_ike_info metric")
+	saCacheEnabled      = flag.Bool("enable-sa-cache", false, "Serve SA metrics from a cache maintained by Vici events")
+	saCacheSync         = flag.Duration("sa-cache-reconcile-interval", defaultSaCacheSync, "Interval of full list-sas reconciles of the SA cache")
+	trafficEnabled      = flag.Bool("enable-traffic-metrics", false, "Enable CHILD SA traffic counters which survive rekeys")
+	eventMetricsEnabled = flag.Bool("enable-event-metrics", false, "Enable IKE and CHILD SA up/down and rekey counters")
+	metricsSchema       = flag.String("metrics-schema", string(strongswan.MetricsSchemaV1), "Metric names and labels (v1, v2)")
 )
 
 func main() {
@@ -55,20 +91,96 @@ func run() (err error) {
 	}
 	defer log.Logger.Sync()
 
+	schema, err := strongswan.ParseMetricsSchema(*metricsSchema)
+	if err != nil {
+		return err
+	}
+	scope, err := strongswan.ParseRevocationScope(*revocationScope)
+	if err != nil {
+		return err
+	}
+
+	charonLocation := time.Local
+	if *charonTimeZone != "" {
+		if charonLocation, err = time.LoadLocation(*charonTimeZone); err != nil {
+			return err
+		}
+	}
+
+	var policy *strongswan.CryptoPolicy
+	if *cryptoPolicy != "" {
+		if policy, err = strongswan.LoadCryptoPolicy(*cryptoPolicy); err != nil {
+			return err
+		}
+	}
+
+	var certLabelList []string
+	if *certLabels != "" {
+		if certLabelList, err = strongswan.ValidateCertLabels(splitList(*certLabels)); err != nil {
+			return err
+		}
+	}
+
 	viciClientFn := func() (strongswan.ViciClient, error) {
 		s, err := vici.NewSession(vici.WithAddr(*viciNetwork, *viciAddr))
 		if err != nil {
 			log.Logger.Warnf("Error connecting to Vici API: %s", err)
+			return nil, err
 		}
-		return s, err
+		return s, nil
 	}
-	cl := strongswan.NewCollector(viciClientFn, *certMetricsEnabled, *connMetricsEnabled)
+	sm := strongswan.NewSessionManager("strongswan_", viciClientFn, *viciMinBackoff, *viciMaxBackoff, *viciTimeout, time.Now)
+	defer sm.Close()
+	el := strongswan.NewEventListener("strongswan_", func() (strongswan.ViciEventClient, error) {
+		s, err := vici.NewSession(vici.WithAddr(*viciNetwork, *viciAddr))
+		if err != nil {
+			log.Logger.Warnf("Error connecting to Vici API for events: %s", err)
+			return nil, err
+		}
+		return s, nil
+	}, *viciMinBackoff, *viciMaxBackoff)
+	cl := strongswan.NewCollector(sm.Client, strongswan.Config{
+		CertMetricsEnabled:       *certMetricsEnabled,
+		CertLabels:               certLabelList,
+		AuthorityMetricsEnabled:  *authoritiesEnabled,
+		CredMetricsEnabled:       *credsEnabled,
+		RevocationMetricsEnabled: *revocationEnabled,
+		RevocationTimeout:        *revocationTimeout,
+		RevocationCacheTTL:       *revocationCacheTTL,
+		RevocationScope:          scope,
+		SwanctlDir:               *swanctlDir,
+		ConnMetricsEnabled:       *connMetricsEnabled,
+		ConnCertMetricsEnabled:   *connCertsEnabled,
+		ConnStatusMetricsEnabled: *connStatusEnabled,
+		CryptoPolicy:             policy,
+		StatsMetricsEnabled:      *statsEnabled,
+		CharonLocation:           charonLocation,
+		CounterMetricsEnabled:    *countersEnabled,
+		AlgorithmMetricsEnabled:  *algorithmsEnabled,
+		PoolMetricsEnabled:       *poolsEnabled,
+		PoolLeaseMetricsEnabled:  *poolLeasesEnabled,
+		PolicyMetricsEnabled:     *policiesEnabled,
+		MetricsSchema:            schema,
+		IkeInfoLabels:            splitList(*ikeInfoLabels),
+		SaCacheEnabled:           *saCacheEnabled,
+		SaCacheReconcileInterval: *saCacheSync,
+		EventMetricsEnabled:      *eventMetricsEnabled,
+		TrafficMetricsEnabled:    *trafficEnabled,
+		Events:                   el,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go el.Run(ctx)
+	cl.Run(ctx)
 
 	checkers := make([]healthcheck.Option, 0)
 	checkers = append(checkers, healthcheck.WithChecker("vici", cl))
 	if err := prometheus.Register(cl); err != nil {
 		return err
 	}
+	if err := prometheus.Register(sm); err != nil {
+		return err
+	}
 	stopFn := startServer(checkers)
 	defer stopFn()
 
@@ -78,6 +190,17 @@ func run() (err error) {
 	return nil
 }
 
+// splitList splits a comma separated flag value, an empty value results in an empty list.
+func splitList(v string) []string {
+	res := make([]string, 0)
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
 func startServer(checkers []healthcheck.Option) func() {
 	mux := http.DefaultServeMux
 	mux.Handle("/healthcheck", http.TimeoutHandler(healthcheck.Handler(checkers...), requestTimeout, "request timeout"))
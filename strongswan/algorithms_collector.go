@@ -0,0 +1,76 @@
+package strongswan
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+// AlgorithmsCollector exposes the algorithms supported by the loaded plugins, as listed by get-algorithms.
+type AlgorithmsCollector struct {
+	viciClientFn viciClientFn
+
+	algorithmInfo *prometheus.Desc
+}
+
+func NewAlgorithmsCollector(prefix string, viciClientFn viciClientFn) prometheus.Collector {
+	return &AlgorithmsCollector{
+		viciClientFn: viciClientFn,
+
+		algorithmInfo: prometheus.NewDesc(
+			prefix+"algorithm_info",
+			"Algorithm supported by the daemon with the plugin providing it",
+			[]string{"type", "algorithm", "plugin"}, nil,
+		),
+	}
+}
+
+func (c *AlgorithmsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.algorithmInfo
+}
+
+func (c *AlgorithmsCollector) Collect(ch chan<- prometheus.Metric) {
+	algorithms, err := c.algorithms()
+	if err != nil {
+		return
+	}
+	for algType, algs := range algorithms {
+		for alg, plugin := range algs {
+			ch <- prometheus.MustNewConstMetric(
+				c.algorithmInfo,
+				prometheus.GaugeValue,
+				1,
+				algType, alg, plugin,
+			)
+		}
+	}
+}
+
+// algorithms returns the providing plugin by algorithm name and type (encryption, integrity, ke, etc.).
+func (c *AlgorithmsCollector) algorithms() (map[string]map[string]string, error) {
+	s, err := c.viciClientFn()
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	m, err := s.CommandRequest("get-algorithms", vici.NewMessage())
+	if err != nil {
+		return nil, err
+	}
+
+	algorithms := make(map[string]map[string]string)
+	for _, algType := range m.Keys() {
+		typeMsg, ok := m.Get(algType).(*vici.Message)
+		if !ok {
+			continue
+		}
+		algs := make(map[string]string)
+		if e := vici.UnmarshalMessage(typeMsg, algs); e != nil {
+			log.Logger.Warnf("Message unmarshal error: %v", e)
+			continue
+		}
+		algorithms[algType] = algs
+	}
+	return algorithms, nil
+}
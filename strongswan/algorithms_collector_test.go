@@ -0,0 +1,71 @@
+package strongswan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func algorithmsMsg(types map[string]map[string]string) *vici.Message {
+	m := vici.NewMessage()
+	for algType, algs := range types {
+		typeMsg := vici.NewMessage()
+		for alg, plugin := range algs {
+			typeMsg.Set(alg, plugin)
+		}
+		m.Set(algType, typeMsg)
+	}
+	return m
+}
+
+func TestAlgorithmsCollector_Metrics(t *testing.T) {
+	msg := algorithmsMsg(map[string]map[string]string{
+		"encryption": {"AES_CBC": "aes", "3DES_CBC": "des"},
+		"ke":         {"ECP_256": "openssl", "ML_KEM_768": "ml"},
+		"rng":        {"RNG_STRONG": "random"},
+	})
+	c := NewAlgorithmsCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{cmdMsgs: map[string]*vici.Message{"get-algorithms": msg}}, nil
+	})
+
+	want := `# HELP swtest_algorithm_info Algorithm supported by the daemon with the plugin providing it
+# TYPE swtest_algorithm_info gauge
+swtest_algorithm_info{algorithm="3DES_CBC",plugin="des",type="encryption"} 1
+swtest_algorithm_info{algorithm="AES_CBC",plugin="aes",type="encryption"} 1
+swtest_algorithm_info{algorithm="ECP_256",plugin="openssl",type="ke"} 1
+swtest_algorithm_info{algorithm="ML_KEM_768",plugin="ml",type="ke"} 1
+swtest_algorithm_info{algorithm="RNG_STRONG",plugin="random",type="rng"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestAlgorithmsCollector_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		viciClientErr error
+		cmdMsgs       map[string]*vici.Message
+	}{
+		{
+			name:          "connection error",
+			viciClientErr: errors.New("some error"),
+		},
+		{
+			name:    "unknown command",
+			cmdMsgs: map[string]*vici.Message{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewAlgorithmsCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{cmdMsgs: tt.cmdMsgs}, tt.viciClientErr
+			})
+			require.Equal(t, 0, testutil.CollectAndCount(c), "metrics count")
+		})
+	}
+}
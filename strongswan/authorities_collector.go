@@ -0,0 +1,283 @@
+package strongswan
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+/*
+AuthoritiesCollector exposes the certification authorities loaded with load-authorities. The CA certificate
+of every authority is looked up in the loaded CA certificates by the attributes of its subject, the info metric carries the
+serial number and subject of the certificate as labelled by the CertsCollector to join the expiry metrics.
+*/
+type AuthoritiesCollector struct {
+	viciClientFn viciClientFn
+	now          func() time.Time
+
+	authorityCnt         *prometheus.Desc
+	authorityInfo        *prometheus.Desc
+	authorityExpireSecs  *prometheus.Desc
+	authorityCrlURIsCnt  *prometheus.Desc
+	authorityOcspURIsCnt *prometheus.Desc
+}
+
+func NewAuthoritiesCollector(prefix string, viciClientFn viciClientFn, now func() time.Time) prometheus.Collector {
+	return &AuthoritiesCollector{
+		viciClientFn: viciClientFn,
+		now:          now,
+
+		authorityCnt: prometheus.NewDesc(
+			prefix+"authority_count",
+			"Number of loaded certification authorities",
+			nil, nil,
+		),
+		authorityInfo: prometheus.NewDesc(
+			prefix+"authority_info",
+			"Certification authority with the serial number and subject of its CA certificate, empty if not loaded",
+			[]string{"authority_name", "cacert", "cert_uri_base", "serial_number", "subject"}, nil,
+		),
+		authorityExpireSecs: prometheus.NewDesc(
+			prefix+"authority_cert_expire_seconds",
+			"Seconds until the CA certificate of the authority expires",
+			[]string{"authority_name"}, nil,
+		),
+		authorityCrlURIsCnt: prometheus.NewDesc(
+			prefix+"authority_crl_uris",
+			"Number of CRL URIs configured for the authority",
+			[]string{"authority_name"}, nil,
+		),
+		authorityOcspURIsCnt: prometheus.NewDesc(
+			prefix+"authority_ocsp_uris",
+			"Number of OCSP URIs configured for the authority",
+			[]string{"authority_name"}, nil,
+		),
+	}
+}
+
+func (c *AuthoritiesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.authorityCnt
+	ch <- c.authorityInfo
+	ch <- c.authorityExpireSecs
+	ch <- c.authorityCrlURIsCnt
+	ch <- c.authorityOcspURIsCnt
+}
+
+func (c *AuthoritiesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, newScrape(c.viciClientFn, nil))
+}
+
+func (c *AuthoritiesCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	authorities, caCerts, err := c.listAuthorities(s)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.authorityCnt,
+			prometheus.GaugeValue,
+			float64(0),
+		)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.authorityCnt,
+		prometheus.GaugeValue,
+		float64(len(authorities)),
+	)
+	now := c.now()
+	for _, a := range authorities {
+		c.collectAuthorityMetrics(a, findCert(caCerts, a.CaCert), now, ch)
+	}
+}
+
+func (c *AuthoritiesCollector) collectAuthorityMetrics(a Authority, cert *x509.Certificate, now time.Time, ch chan<- prometheus.Metric) {
+	var serialNumber, subject string
+	if cert != nil {
+		serialNumber, subject = formatSerialNumber(cert.SerialNumber), cert.Subject.String()
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.authorityInfo,
+		prometheus.GaugeValue,
+		1,
+		a.Name, a.CaCert, a.CertURIBase, serialNumber, subject,
+	)
+	if cert != nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.authorityExpireSecs,
+			prometheus.GaugeValue,
+			cert.NotAfter.Sub(now).Seconds(),
+			a.Name,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.authorityCrlURIsCnt,
+		prometheus.GaugeValue,
+		float64(len(a.CrlURIs)),
+		a.Name,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.authorityOcspURIsCnt,
+		prometheus.GaugeValue,
+		float64(len(a.OcspURIs)),
+		a.Name,
+	)
+}
+
+// listAuthorities returns the loaded authorities together with the parsed CA certificates.
+func (c *AuthoritiesCollector) listAuthorities(sc *scrape) ([]Authority, []*x509.Certificate, error) {
+	s, err := c.viciClientFn()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.Close()
+
+	msgs, err := s.StreamedCommandRequest("list-authorities", "list-authority", vici.NewMessage())
+	if err != nil {
+		return nil, nil, err
+	}
+	var authorities []Authority
+	for _, m := range msgs {
+		if err = m.Err(); err != nil {
+			log.Logger.Warnf("Message error: %v", err)
+			return nil, nil, err
+		}
+		for _, name := range m.Keys() {
+			authorityMsg, ok := m.Get(name).(*vici.Message)
+			if !ok {
+				continue
+			}
+			var authority Authority
+			if e := vici.UnmarshalMessage(authorityMsg, &authority); e != nil {
+				log.Logger.Warnf("Message unmarshal error: %v", e)
+				continue
+			}
+			authority.Name = name
+			authorities = append(authorities, authority)
+		}
+	}
+
+	certs, err := sc.listCerts()
+	if err != nil {
+		return nil, nil, err
+	}
+	var caCerts []Cert
+	for _, vc := range certs {
+		if vc.Flag == "CA" {
+			caCerts = append(caCerts, vc)
+		}
+	}
+	return authorities, parseX509Certs(caCerts), nil
+}
+
+// findCert returns the certificate with the subject DN as formatted by strongSwan, or nil if there is none.
+func findCert(certs []*x509.Certificate, subject string) *x509.Certificate {
+	for _, cert := range certs {
+		if hasSubject(cert, subject) {
+			return cert
+		}
+	}
+	return nil
+}
+
+/*
+hasSubject reports if the subject encoded in the certificate has the attributes of the DN as formatted by
+strongSwan, e.g. "C=CH, O=Cyber, CN=Cyber Root CA". The attributes are compared by their type and value in the
+order of the encoding, so values with separators or escaped characters do not depend on the string formatting.
+*/
+func hasSubject(cert *x509.Certificate, dn string) bool {
+	want := parseDN(dn)
+	if want == nil {
+		return false
+	}
+	var rdns pkix.RDNSequence
+	if _, err := asn1.Unmarshal(cert.RawSubject, &rdns); err != nil {
+		return false
+	}
+	return slices.EqualFunc(slices.Concat(rdns...), want, func(a pkix.AttributeTypeAndValue, b pkix.AttributeTypeAndValue) bool {
+		return a.Type.Equal(b.Type) && fmt.Sprint(a.Value) == fmt.Sprint(b.Value)
+	})
+}
+
+// dnAttributeTypes are the attribute types strongSwan formats by name, other types are formatted as OID.
+var dnAttributeTypes = map[string]asn1.ObjectIdentifier{
+	"CN":           {2, 5, 4, 3},
+	"S":            {2, 5, 4, 4},
+	"SN":           {2, 5, 4, 5},
+	"serialNumber": {2, 5, 4, 5},
+	"C":            {2, 5, 4, 6},
+	"L":            {2, 5, 4, 7},
+	"ST":           {2, 5, 4, 8},
+	"STREET":       {2, 5, 4, 9},
+	"O":            {2, 5, 4, 10},
+	"OU":           {2, 5, 4, 11},
+	"T":            {2, 5, 4, 12},
+	"D":            {2, 5, 4, 13},
+	"N":            {2, 5, 4, 41},
+	"G":            {2, 5, 4, 42},
+	"I":            {2, 5, 4, 43},
+	"dnQualifier":  {2, 5, 4, 46},
+	"ID":           {2, 5, 4, 45},
+	"pseudonym":    {2, 5, 4, 65},
+	"E":            {1, 2, 840, 113549, 1, 9, 1},
+	"Email":        {1, 2, 840, 113549, 1, 9, 1},
+	"emailAddress": {1, 2, 840, 113549, 1, 9, 1},
+	"UN":           {1, 2, 840, 113549, 1, 9, 2},
+	"UID":          {0, 9, 2342, 19200300, 100, 1, 1},
+	"DC":           {0, 9, 2342, 19200300, 100, 1, 25},
+}
+
+// dnAttribute matches the type of an attribute at the start of the DN or after the separator.
+var dnAttribute = regexp.MustCompile(`(?:^|, )([A-Za-z]+|[0-9]+(?:\.[0-9]+)+)=`)
+
+/*
+parseDN returns the attributes of the DN as formatted by strongSwan, or nil if it is no DN. Values are not escaped
+by strongSwan, so an attribute only starts at a separator followed by a known type.
+*/
+func parseDN(dn string) []pkix.AttributeTypeAndValue {
+	type attribute struct {
+		oid        asn1.ObjectIdentifier
+		start      int
+		valueStart int
+	}
+	var attrs []attribute
+	for _, m := range dnAttribute.FindAllStringSubmatchIndex(dn, -1) {
+		if oid, ok := dnAttributeType(dn[m[2]:m[3]]); ok {
+			attrs = append(attrs, attribute{oid: oid, start: m[0], valueStart: m[1]})
+		}
+	}
+	if len(attrs) == 0 || attrs[0].start != 0 {
+		return nil
+	}
+	atvs := make([]pkix.AttributeTypeAndValue, len(attrs))
+	for i, a := range attrs {
+		end := len(dn)
+		if i+1 < len(attrs) {
+			end = attrs[i+1].start
+		}
+		atvs[i] = pkix.AttributeTypeAndValue{Type: a.oid, Value: dn[a.valueStart:end]}
+	}
+	return atvs
+}
+
+func dnAttributeType(name string) (asn1.ObjectIdentifier, bool) {
+	if oid, ok := dnAttributeTypes[name]; ok {
+		return oid, true
+	}
+	var oid asn1.ObjectIdentifier
+	for _, arc := range strings.Split(name, ".") {
+		n, err := strconv.Atoi(arc)
+		if err != nil {
+			return nil, false
+		}
+		oid = append(oid, n)
+	}
+	return oid, true
+}
@@ -0,0 +1,148 @@
+package strongswan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func authorityMsg(name string, cacert string, crlURIs []string, ocspURIs []string) *vici.Message {
+	a := vici.NewMessage()
+	a.Set("cacert", cacert)
+	if len(crlURIs) > 0 {
+		a.Set("crl_uris", crlURIs)
+	}
+	if len(ocspURIs) > 0 {
+		a.Set("ocsp_uris", ocspURIs)
+	}
+	a.Set("cert_uri_base", "http://ca.example.local/certs/")
+	m := vici.NewMessage()
+	m.Set(name, a)
+	return m
+}
+
+func caCertMsg(path string) *vici.Message {
+	m := vici.NewMessage()
+	m.Set("type", "X509")
+	m.Set("flag", "CA")
+	m.Set("data", loadCert(path))
+	return m
+}
+
+func TestAuthoritiesCollector_Metrics(t *testing.T) {
+	tests := []struct {
+		name              string
+		viciClientErr     error
+		viciSessionErr    error
+		authorityMsgs     []*vici.Message
+		certMsgs          []*vici.Message
+		metricName        string
+		wantMetricsHelp   string
+		wantMetricsLabels string
+		wantMetricsValue  float64
+		wantMetricsCount  int
+	}{
+		{
+			name:             "connection error",
+			viciClientErr:    errors.New("some error"),
+			metricName:       "swtest_authority_count",
+			wantMetricsHelp:  "Number of loaded certification authorities",
+			wantMetricsValue: 0,
+			wantMetricsCount: 1,
+		},
+		{
+			name:             "session error",
+			viciSessionErr:   errors.New("some error"),
+			authorityMsgs:    []*vici.Message{authorityMsg("cyber", "C=CH, O=Cyber, CN=Cyber Root CA", nil, nil)},
+			metricName:       "swtest_authority_count",
+			wantMetricsHelp:  "Number of loaded certification authorities",
+			wantMetricsValue: 0,
+			wantMetricsCount: 1,
+		},
+		{
+			name:              "authority with loaded CA certificate",
+			authorityMsgs:     []*vici.Message{authorityMsg("cyber", "C=CH, O=Cyber, CN=Cyber Root CA", nil, nil)},
+			certMsgs:          []*vici.Message{caCertMsg("testdata/cert-expired.pem"), caCertMsg("testdata/cert-ca.pem")},
+			metricName:        "swtest_authority_info",
+			wantMetricsHelp:   "Certification authority with the serial number and subject of its CA certificate, empty if not loaded",
+			wantMetricsLabels: `authority_name="cyber",cacert="C=CH, O=Cyber, CN=Cyber Root CA",cert_uri_base="http://ca.example.local/certs/",serial_number="63:68:4d:00:11:20:7d:dc",subject="CN=Cyber Root CA,O=Cyber,C=CH"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "CA certificate with a separator in its subject",
+			authorityMsgs:     []*vici.Message{authorityMsg("cyber-inc", "C=CH, O=Cyber, Inc., CN=Cyber Inc Root CA", nil, nil)},
+			certMsgs:          []*vici.Message{caCertMsg("testdata/cert-ca.pem"), caCertMsg("testdata/cert-ca-comma.pem")},
+			metricName:        "swtest_authority_info",
+			wantMetricsHelp:   "Certification authority with the serial number and subject of its CA certificate, empty if not loaded",
+			wantMetricsLabels: `authority_name="cyber-inc",cacert="C=CH, O=Cyber, Inc., CN=Cyber Inc Root CA",cert_uri_base="http://ca.example.local/certs/",serial_number="1f:d3:ee:ff:b9:c2:92:9b:95:6e:99:ee:9a:50:ff:eb:48:14:d6:d9",subject="CN=Cyber Inc Root CA,O=Cyber\\, Inc.,C=CH"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "authority without CA certificate",
+			authorityMsgs:     []*vici.Message{authorityMsg("other", "C=CH, O=Other, CN=Other Root CA", nil, nil)},
+			certMsgs:          []*vici.Message{caCertMsg("testdata/cert-ca.pem")},
+			metricName:        "swtest_authority_info",
+			wantMetricsHelp:   "Certification authority with the serial number and subject of its CA certificate, empty if not loaded",
+			wantMetricsLabels: `authority_name="other",cacert="C=CH, O=Other, CN=Other Root CA",cert_uri_base="http://ca.example.local/certs/",serial_number="",subject=""`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  4,
+		},
+		{
+			name:              "CA certificate expiry",
+			authorityMsgs:     []*vici.Message{authorityMsg("cyber", "C=CH, O=Cyber, CN=Cyber Root CA", nil, nil)},
+			certMsgs:          []*vici.Message{caCertMsg("testdata/cert-ca.pem")},
+			metricName:        "swtest_authority_cert_expire_seconds",
+			wantMetricsHelp:   "Seconds until the CA certificate of the authority expires",
+			wantMetricsLabels: `authority_name="cyber"`,
+			wantMetricsValue:  86400,
+			wantMetricsCount:  5,
+		},
+		{
+			name: "CRL URIs",
+			authorityMsgs: []*vici.Message{authorityMsg("cyber", "C=CH, O=Cyber, CN=Cyber Root CA",
+				[]string{"http://ca.example.local/root.crl", "ldap://ca.example.local/root.crl"}, nil)},
+			metricName:        "swtest_authority_crl_uris",
+			wantMetricsHelp:   "Number of CRL URIs configured for the authority",
+			wantMetricsLabels: `authority_name="cyber"`,
+			wantMetricsValue:  2,
+			wantMetricsCount:  4,
+		},
+		{
+			name: "OCSP URIs",
+			authorityMsgs: []*vici.Message{authorityMsg("cyber", "C=CH, O=Cyber, CN=Cyber Root CA",
+				nil, []string{"http://ocsp.example.local"})},
+			metricName:        "swtest_authority_ocsp_uris",
+			wantMetricsHelp:   "Number of OCSP URIs configured for the authority",
+			wantMetricsLabels: `authority_name="cyber"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := func() time.Time { return time.Date(2034, 3, 19, 15, 1, 4, 0, time.UTC) }
+			c := NewAuthoritiesCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{authorityMsgs: tt.authorityMsgs, certMsgs: tt.certMsgs, err: tt.viciSessionErr}, tt.viciClientErr
+			}, now)
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
+
+			wantMetricsContent := fmt.Sprintf(`# HELP %s %s
+# TYPE %s gauge
+%s{%s} %v
+`, tt.metricName, tt.wantMetricsHelp, tt.metricName, tt.metricName, tt.wantMetricsLabels, tt.wantMetricsValue)
+			if err := testutil.CollectAndCompare(c, strings.NewReader(wantMetricsContent), tt.metricName); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
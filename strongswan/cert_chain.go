@@ -0,0 +1,88 @@
+package strongswan
+
+import (
+	"bytes"
+	"crypto/x509"
+	"time"
+)
+
+/*
+certChains builds the chains of end-entity certificates from the loaded CA certificates. Every CA certificate
+charon loaded is a trust anchor, whether self-signed or not, e.g. an intermediate CA certificate from the x509ca
+directory.
+*/
+type certChains struct {
+	cas []*x509.Certificate
+}
+
+func newCertChains(cas []*x509.Certificate) *certChains {
+	return &certChains{cas: cas}
+}
+
+/*
+verify reports if a loaded CA certificate issued the certificate and all certificates of its chain are valid at
+the time. Unlike x509.Certificate.Verify, SHA-1 signatures are accepted like charon does, while name constraints
+and key usages are not checked.
+*/
+func (c *certChains) verify(cert *x509.Certificate, now time.Time) bool {
+	chain := c.chain(cert)
+	if len(chain) < 2 {
+		return false
+	}
+	for _, cert := range chain {
+		if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			return false
+		}
+	}
+	return true
+}
+
+// notAfter returns the earliest expiry of the certificate and its issuers, so an incomplete chain still reports its known part.
+func (c *certChains) notAfter(cert *x509.Certificate) time.Time {
+	notAfter := cert.NotAfter
+	for _, cert := range c.chain(cert) {
+		if cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	return notAfter
+}
+
+// chain follows the issuers of the certificate until a self-signed certificate or an issuer that is not loaded.
+func (c *certChains) chain(cert *x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{cert}
+	// bounded by the number of CAs to stop on cross-signed loops
+	for range len(c.cas) {
+		if isSelfSigned(cert) {
+			break
+		}
+		issuer := c.issuer(cert)
+		if issuer == nil {
+			break
+		}
+		chain = append(chain, issuer)
+		cert = issuer
+	}
+	return chain
+}
+
+// issuer returns the loaded CA certificate that signed the certificate, the one valid the longest if the CA was renewed.
+func (c *certChains) issuer(cert *x509.Certificate) *x509.Certificate {
+	var issuer *x509.Certificate
+	for _, ca := range c.cas {
+		if issuedBy(cert, ca) && (issuer == nil || ca.NotAfter.After(issuer.NotAfter)) {
+			issuer = ca
+		}
+	}
+	return issuer
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return issuedBy(cert, cert)
+}
+
+// issuedBy checks the signature with CheckSignature, as CheckSignatureFrom rejects SHA-1 signatures.
+func issuedBy(cert *x509.Certificate, issuer *x509.Certificate) bool {
+	return bytes.Equal(issuer.RawSubject, cert.RawIssuer) &&
+		issuer.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
@@ -0,0 +1,180 @@
+package strongswan
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+)
+
+var (
+	// oidOcspBasic is the response type of basic OCSP responses, RFC 6960.
+	oidOcspBasic = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidSha1      = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+)
+
+// signatureAlgorithms maps the OIDs of the supported OCSP response signature algorithms.
+var signatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	"1.2.840.113549.1.1.5":  x509.SHA1WithRSA,
+	"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+	"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+	"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+	"1.2.840.10045.4.1":     x509.ECDSAWithSHA1,
+	"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+	"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+	"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+	"1.3.101.112":           x509.PureEd25519,
+}
+
+// attributeCert is the part of an attribute certificate (RFC 5755) needed to check its validity.
+type attributeCert struct {
+	Info struct {
+		Version      int
+		Holder       asn1.RawValue
+		Issuer       asn1.RawValue
+		Signature    pkix.AlgorithmIdentifier
+		SerialNumber *big.Int
+		Validity     struct {
+			NotBefore time.Time `asn1:"generalized"`
+			NotAfter  time.Time `asn1:"generalized"`
+		}
+	}
+}
+
+func parseAttributeCert(der []byte) (*attributeCert, error) {
+	var ac attributeCert
+	if _, err := asn1.Unmarshal(der, &ac); err != nil {
+		return nil, err
+	}
+	return &ac, nil
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response struct {
+		ResponseType asn1.ObjectIdentifier
+		Response     []byte
+	} `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData struct {
+		Raw         asn1.RawContent
+		Version     int `asn1:"optional,default:0,explicit,tag:0"`
+		ResponderID asn1.RawValue
+		ProducedAt  time.Time `asn1:"generalized"`
+		Responses   []ocspSingleResponse
+	}
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certs              []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Good       asn1.Flag       `asn1:"tag:0,optional"`
+	Revoked    ocspRevokedInfo `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag       `asn1:"tag:2,optional"`
+	ThisUpdate time.Time       `asn1:"generalized"`
+	NextUpdate time.Time       `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspRequest struct {
+	TBSRequest struct {
+		RequestList []ocspSingleRequest
+	}
+}
+
+type ocspSingleRequest struct {
+	ReqCert ocspCertID
+}
+
+// newOcspRequest returns an unsigned OCSP request for the certificate, which is identified by the SHA-1 hashes of its issuer.
+func newOcspRequest(cert *x509.Certificate, issuer *x509.Certificate) ([]byte, error) {
+	keyBits, err := publicKeyBits(issuer.RawSubjectPublicKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+	nameHash := sha1.Sum(issuer.RawSubject)
+	keyHash := sha1.Sum(keyBits)
+
+	var req ocspRequest
+	req.TBSRequest.RequestList = []ocspSingleRequest{{
+		ReqCert: ocspCertID{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSha1, Parameters: asn1.NullRawValue},
+			NameHash:      nameHash[:],
+			IssuerKeyHash: keyHash[:],
+			SerialNumber:  cert.SerialNumber,
+		},
+	}}
+	return asn1.Marshal(req)
+}
+
+// parseOcspResponses returns the single responses of a successful basic OCSP response, the signature is not verified.
+func parseOcspResponses(der []byte) ([]ocspSingleResponse, error) {
+	basic, err := parseOcspBasicResponse(der)
+	if err != nil {
+		return nil, err
+	}
+	return basic.TBSResponseData.Responses, nil
+}
+
+func parseOcspBasicResponse(der []byte) (*ocspBasicResponse, error) {
+	var resp ocspResponse
+	if _, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Status != 0 {
+		return nil, fmt.Errorf("unsuccessful OCSP response status: %d", resp.Status)
+	}
+	if !resp.Response.ResponseType.Equal(oidOcspBasic) {
+		return nil, errors.New("no basic OCSP response")
+	}
+	var basic ocspBasicResponse
+	if _, err := asn1.Unmarshal(resp.Response.Response, &basic); err != nil {
+		return nil, err
+	}
+	return &basic, nil
+}
+
+/*
+checkSignatureFrom verifies that the OCSP response is signed by the issuer of the certificates it is about, or
+by a delegated responder certificate included in the response, issued by it for OCSP signing.
+*/
+func (r *ocspBasicResponse) checkSignatureFrom(issuer *x509.Certificate) error {
+	algo, ok := signatureAlgorithms[r.SignatureAlgorithm.Algorithm.String()]
+	if !ok {
+		return fmt.Errorf("unsupported OCSP response signature algorithm: %s", r.SignatureAlgorithm.Algorithm)
+	}
+	tbs, sig := r.TBSResponseData.Raw, r.Signature.RightAlign()
+	if issuer.CheckSignature(algo, tbs, sig) == nil {
+		return nil
+	}
+	for _, raw := range r.Certs {
+		responder, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil || !slices.Contains(responder.ExtKeyUsage, x509.ExtKeyUsageOCSPSigning) {
+			continue
+		}
+		if responder.CheckSignatureFrom(issuer) == nil && responder.CheckSignature(algo, tbs, sig) == nil {
+			return nil
+		}
+	}
+	return errors.New("OCSP response is not signed by the issuer or a delegated responder")
+}
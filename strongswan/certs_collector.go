@@ -1,9 +1,16 @@
 package strongswan
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"math/big"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,51 +19,251 @@ import (
 	"github.com/torilabs/ipsec-prometheus-exporter/log"
 )
 
+// certLabels are the labels which can identify a certificate on the validity metrics, in their exported order.
+var certLabels = []string{"serial_number", "subject", "subject_cn", "issuer", "issuer_cn", "fingerprint_sha256", "not_before", "not_after"}
+
+// certInfoLabels are the labels of the certificate info metric next to the identifying labels.
+var certInfoLabels = []string{"issuer", "fingerprint_sha256", "sans", "key_algorithm", "key_size", "signature_algorithm", "flag", "has_privkey"}
+
+var certLabelValues = map[string]func(*x509.Certificate, Cert) string{
+	"serial_number":       func(c *x509.Certificate, _ Cert) string { return formatSerialNumber(c.SerialNumber) },
+	"subject":             func(c *x509.Certificate, _ Cert) string { return c.Subject.String() },
+	"subject_cn":          func(c *x509.Certificate, _ Cert) string { return c.Subject.CommonName },
+	"issuer":              func(c *x509.Certificate, _ Cert) string { return c.Issuer.String() },
+	"issuer_cn":           func(c *x509.Certificate, _ Cert) string { return c.Issuer.CommonName },
+	"fingerprint_sha256":  func(c *x509.Certificate, _ Cert) string { return fingerprint(c) },
+	"not_before":          func(c *x509.Certificate, _ Cert) string { return c.NotBefore.Format(time.RFC3339) },
+	"not_after":           func(c *x509.Certificate, _ Cert) string { return c.NotAfter.Format(time.RFC3339) },
+	"sans":                func(c *x509.Certificate, _ Cert) string { return strings.Join(subjectAltNames(c), ";") },
+	"key_algorithm":       func(c *x509.Certificate, _ Cert) string { return c.PublicKeyAlgorithm.String() },
+	"key_size":            func(c *x509.Certificate, _ Cert) string { return strconv.Itoa(keySize(c)) },
+	"signature_algorithm": func(c *x509.Certificate, _ Cert) string { return c.SignatureAlgorithm.String() },
+	"flag":                func(_ *x509.Certificate, vc Cert) string { return vc.Flag },
+	"has_privkey":         func(_ *x509.Certificate, vc Cert) string { return strconv.FormatBool(vc.HasPrivkey == "yes") },
+}
+
 type CertsCollector struct {
 	viciClientFn viciClientFn
+	loc          *time.Location
 	now          func() time.Time
+	schema       MetricsSchema
+	labels       []string
+	infoLabels   []string
 
 	certCnt        *prometheus.Desc
+	certInfo       *prometheus.Desc
 	certValid      *prometheus.Desc
 	certExpireSecs *prometheus.Desc
+	certNotBefore  *prometheus.Desc
+	certNotAfter   *prometheus.Desc
+
+	chainValid      *prometheus.Desc
+	chainExpireSecs *prometheus.Desc
+
+	crlValid         *prometheus.Desc
+	crlExpireSecs    *prometheus.Desc
+	crlThisUpdate    *prometheus.Desc
+	crlNextUpdate    *prometheus.Desc
+	crlRevokedCnt    *prometheus.Desc
+	acValid          *prometheus.Desc
+	acExpireSecs     *prometheus.Desc
+	ocspValid        *prometheus.Desc
+	ocspExpireSecs   *prometheus.Desc
+	pubkeyValid      *prometheus.Desc
+	pubkeyExpireSecs *prometheus.Desc
 }
 
 const (
-	typeX509Cert = "X509"
-	keyType      = "type"
+	typeAnyCert      = "ANY"
+	typeX509Cert     = "X509"
+	typeX509CRL      = "X509_CRL"
+	typeX509AC       = "X509_AC"
+	typeOcspResponse = "OCSP_RESPONSE"
+	typePubkey       = "PUBKEY"
+	keyType          = "type"
 )
 
-func NewCertsCollector(prefix string, viciClientFn viciClientFn, now func() time.Time) prometheus.Collector {
-	return &CertsCollector{
+// certTypes are the certificate types exported by the CertsCollector.
+var certTypes = []string{typeX509Cert, typeX509CRL, typeX509AC, typeOcspResponse, typePubkey}
+
+/*
+NewCertsCollector creates the collector, labels selects the labels identifying a certificate on the validity
+metrics, nil for the labels of the metrics schema. The validity of raw public keys is printed in the local
+time of charon, which is parsed in the given location.
+*/
+func NewCertsCollector(prefix string, viciClientFn viciClientFn, loc *time.Location, now func() time.Time, schema MetricsSchema, labels []string) prometheus.Collector {
+	if labels == nil {
+		// v2 exports the validity period as timestamp metrics instead of labels.
+		labels = schema.labels([]string{"serial_number", "subject", "not_before", "not_after"}, []string{"serial_number", "subject"})
+	}
+	infoLabels := slices.DeleteFunc(slices.Clone(certInfoLabels), func(l string) bool { return slices.Contains(labels, l) })
+	c := &CertsCollector{
 		viciClientFn: viciClientFn,
+		loc:          loc,
 		now:          now,
+		schema:       schema,
+		labels:       labels,
+		infoLabels:   infoLabels,
 
 		certCnt: prometheus.NewDesc(
 			prefix+"cert_count",
 			"Number of X509 certificates",
 			nil, nil,
 		),
+		certInfo: prometheus.NewDesc(
+			prefix+"cert_info",
+			"X509 certificate metadata and Vici flags",
+			append(slices.Clone(labels), infoLabels...), nil,
+		),
 		certValid: prometheus.NewDesc(
 			prefix+"cert_valid",
 			"X509 certificate validity",
-			[]string{"serial_number", "subject", "not_before", "not_after"}, nil,
+			labels, nil,
 		),
 		certExpireSecs: prometheus.NewDesc(
-			prefix+"cert_expire_secs",
+			prefix+schema.name("cert_expire_secs", "cert_expire_seconds"),
 			"Seconds until the X509 certificate expires",
-			[]string{"serial_number", "subject", "not_before", "not_after"}, nil,
+			labels, nil,
+		),
+
+		chainValid: prometheus.NewDesc(
+			prefix+"cert_chain_valid",
+			"Validity of the chain of the X509 end-entity certificate up to a loaded CA certificate",
+			labels, nil,
+		),
+		chainExpireSecs: prometheus.NewDesc(
+			prefix+"cert_chain_expire_seconds",
+			"Seconds until the first certificate in the chain of the X509 end-entity certificate expires",
+			labels, nil,
+		),
+
+		crlValid: prometheus.NewDesc(
+			prefix+"crl_valid",
+			"X509 CRL validity, 0 if the next update is overdue",
+			[]string{"issuer", "crl_number"}, nil,
+		),
+		crlExpireSecs: prometheus.NewDesc(
+			prefix+"crl_expire_seconds",
+			"Seconds until the next update of the X509 CRL",
+			[]string{"issuer", "crl_number"}, nil,
+		),
+		crlThisUpdate: prometheus.NewDesc(
+			prefix+"crl_this_update_timestamp_seconds",
+			"Issue date of the X509 CRL as Unix timestamp",
+			[]string{"issuer", "crl_number"}, nil,
+		),
+		crlNextUpdate: prometheus.NewDesc(
+			prefix+"crl_next_update_timestamp_seconds",
+			"Next update of the X509 CRL as Unix timestamp",
+			[]string{"issuer", "crl_number"}, nil,
+		),
+		crlRevokedCnt: prometheus.NewDesc(
+			prefix+"crl_revoked_certs",
+			"Number of revoked certificates listed in the X509 CRL",
+			[]string{"issuer", "crl_number"}, nil,
+		),
+		acValid: prometheus.NewDesc(
+			prefix+"ac_valid",
+			"X509 attribute certificate validity",
+			[]string{"serial_number"}, nil,
+		),
+		acExpireSecs: prometheus.NewDesc(
+			prefix+"ac_expire_seconds",
+			"Seconds until the X509 attribute certificate expires",
+			[]string{"serial_number"}, nil,
 		),
+		ocspValid: prometheus.NewDesc(
+			prefix+"ocsp_response_valid",
+			"OCSP response validity, 0 if the next update is overdue",
+			[]string{"serial_number"}, nil,
+		),
+		ocspExpireSecs: prometheus.NewDesc(
+			prefix+"ocsp_response_expire_seconds",
+			"Seconds until the next update of the OCSP response",
+			[]string{"serial_number"}, nil,
+		),
+		pubkeyValid: prometheus.NewDesc(
+			prefix+"pubkey_valid",
+			"Raw public key validity",
+			[]string{"subject"}, nil,
+		),
+		pubkeyExpireSecs: prometheus.NewDesc(
+			prefix+"pubkey_expire_seconds",
+			"Seconds until the raw public key expires",
+			[]string{"subject"}, nil,
+		),
+	}
+	if schema.v2() {
+		c.certNotBefore = prometheus.NewDesc(
+			prefix+"cert_not_before_timestamp_seconds",
+			"Start of the X509 certificate validity as Unix timestamp",
+			labels, nil,
+		)
+		c.certNotAfter = prometheus.NewDesc(
+			prefix+"cert_not_after_timestamp_seconds",
+			"End of the X509 certificate validity as Unix timestamp",
+			labels, nil,
+		)
+	}
+	return c
+}
+
+// uniqueCertLabels are the labels of which one identifies a certificate, other labels are shared by certificates,
+// e.g. the subject_cn of a renewed certificate, and would export the same label values twice.
+var uniqueCertLabels = []string{"serial_number", "fingerprint_sha256"}
+
+/*
+ValidateCertLabels orders the labels identifying a certificate on the validity metrics. It fails if a label is
+unknown or if none of the labels identifies a certificate, as certificates sharing the labels would fail the scrape.
+*/
+func ValidateCertLabels(labels []string) ([]string, error) {
+	for _, l := range labels {
+		if !slices.Contains(certLabels, l) {
+			return nil, fmt.Errorf("unknown certificate label: '%v'", l)
+		}
+	}
+	var valid []string
+	for _, l := range certLabels {
+		if slices.Contains(labels, l) {
+			valid = append(valid, l)
+		}
+	}
+	if !slices.ContainsFunc(valid, func(l string) bool { return slices.Contains(uniqueCertLabels, l) }) {
+		return nil, fmt.Errorf("certificate labels %v do not identify a certificate, add one of %v", valid, uniqueCertLabels)
 	}
+	return valid, nil
 }
 
 func (c *CertsCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.certCnt
+	ch <- c.certInfo
 	ch <- c.certValid
 	ch <- c.certExpireSecs
+	if c.schema.v2() {
+		ch <- c.certNotBefore
+		ch <- c.certNotAfter
+	}
+	ch <- c.chainValid
+	ch <- c.chainExpireSecs
+	ch <- c.crlValid
+	ch <- c.crlExpireSecs
+	ch <- c.crlThisUpdate
+	ch <- c.crlNextUpdate
+	ch <- c.crlRevokedCnt
+	ch <- c.acValid
+	ch <- c.acExpireSecs
+	ch <- c.ocspValid
+	ch <- c.ocspExpireSecs
+	ch <- c.pubkeyValid
+	ch <- c.pubkeyExpireSecs
 }
 
 func (c *CertsCollector) Collect(ch chan<- prometheus.Metric) {
-	certs, err := c.listCerts()
+	c.collect(ch, newScrape(c.viciClientFn, nil))
+}
+
+func (c *CertsCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	certs, err := s.listCerts()
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(
 			c.certCnt,
@@ -65,63 +272,304 @@ func (c *CertsCollector) Collect(ch chan<- prometheus.Metric) {
 		)
 		return
 	}
+	x509Cnt := 0
+	for _, cert := range certs {
+		if cert.Type == typeX509Cert {
+			x509Cnt++
+		}
+	}
 	ch <- prometheus.MustNewConstMetric(
 		c.certCnt,
 		prometheus.GaugeValue,
-		float64(len(certs)),
+		float64(x509Cnt),
 	)
 	c.collectCertMetrics(certs, ch)
 }
 
 func (c *CertsCollector) collectCertMetrics(certs []Cert, ch chan<- prometheus.Metric) {
 	now := c.now()
-	for _, cert := range certs {
-		if cert.Type != typeX509Cert {
-			log.Logger.Warnf("Unknown certificate type: '%v'", cert.Type)
+	parsed := make(map[int]*x509.Certificate, len(certs))
+	var cas []*x509.Certificate
+	for i, vc := range certs {
+		if vc.Type != typeX509Cert {
 			continue
 		}
-
-		cert, err := x509.ParseCertificate([]byte(cert.Data))
+		cert, err := x509.ParseCertificate([]byte(vc.Data))
 		if err != nil {
 			log.Logger.Warnf("Certificate parse error: %v", err)
 			continue
 		}
+		parsed[i] = cert
+		if isCACert(vc, cert) {
+			cas = append(cas, cert)
+		}
+	}
+	chains := newCertChains(cas)
 
-		valid := 0
-		if now.After(cert.NotBefore) && now.Before(cert.NotAfter) {
-			valid = 1
+	for i, cert := range certs {
+		switch cert.Type {
+		case typeX509Cert:
+			if x509Cert, ok := parsed[i]; ok {
+				c.collectX509Metrics(cert, x509Cert, chains, now, ch)
+			}
+		case typeX509CRL:
+			c.collectCrlMetrics(cert, now, ch)
+		case typeX509AC:
+			c.collectAcMetrics(cert, now, ch)
+		case typeOcspResponse:
+			c.collectOcspMetrics(cert, now, ch)
+		case typePubkey:
+			c.collectPubkeyMetrics(cert, now, ch)
+		default:
+			log.Logger.Warnf("Unknown certificate type: '%v'", cert.Type)
 		}
-		expireIn := cert.NotAfter.Sub(now).Seconds()
+	}
+}
+
+func (c *CertsCollector) collectX509Metrics(vc Cert, cert *x509.Certificate, chains *certChains, now time.Time, ch chan<- prometheus.Metric) {
+	valid := 0
+	if now.After(cert.NotBefore) && now.Before(cert.NotAfter) {
+		valid = 1
+	}
+	expireIn := cert.NotAfter.Sub(now).Seconds()
+
+	labels := make([]string, 0, len(c.labels)+len(c.infoLabels))
+	for _, l := range c.labels {
+		labels = append(labels, certLabelValues[l](cert, vc))
+	}
+	infoLabels := slices.Clone(labels)
+	for _, l := range c.infoLabels {
+		infoLabels = append(infoLabels, certLabelValues[l](cert, vc))
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.certInfo,
+		prometheus.GaugeValue,
+		1,
+		infoLabels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.certValid,
+		prometheus.GaugeValue,
+		float64(valid),
+		labels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.certExpireSecs,
+		prometheus.GaugeValue,
+		expireIn,
+		labels...,
+	)
+	if c.schema.v2() {
+		ch <- prometheus.MustNewConstMetric(
+			c.certNotBefore,
+			prometheus.GaugeValue,
+			float64(cert.NotBefore.Unix()),
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.certNotAfter,
+			prometheus.GaugeValue,
+			float64(cert.NotAfter.Unix()),
+			labels...,
+		)
+	}
+	if isCACert(vc, cert) {
+		return
+	}
+	chainValid := 0
+	if chains.verify(cert, now) {
+		chainValid = 1
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.chainValid,
+		prometheus.GaugeValue,
+		float64(chainValid),
+		labels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.chainExpireSecs,
+		prometheus.GaugeValue,
+		chains.notAfter(cert).Sub(now).Seconds(),
+		labels...,
+	)
+}
+
+// isCACert reports if the certificate is loaded as CA certificate or is one by its basic constraints.
+func isCACert(vc Cert, cert *x509.Certificate) bool {
+	return vc.Flag == "CA" || cert.IsCA
+}
+
+// fingerprint is the SHA-256 hash of the DER encoded certificate.
+func fingerprint(cert *x509.Certificate) string {
+	h := sha256.Sum256(cert.Raw)
+	return formatHexStrWithColons(hex.EncodeToString(h[:]))
+}
+
+func subjectAltNames(cert *x509.Certificate) []string {
+	sans := slices.Concat(cert.DNSNames, cert.EmailAddresses)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
+
+func keySize(cert *x509.Certificate) int {
+	switch k := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	default:
+		return 0
+	}
+}
+
+func (c *CertsCollector) collectCrlMetrics(vc Cert, now time.Time, ch chan<- prometheus.Metric) {
+	crl, err := x509.ParseRevocationList([]byte(vc.Data))
+	if err != nil {
+		log.Logger.Warnf("CRL parse error: %v", err)
+		return
+	}
+	var number string
+	if crl.Number != nil {
+		number = crl.Number.String()
+	}
+	labels := []string{crl.Issuer.String(), number}
 
-		labels := make([]string, 4)
-		labels[0] = formatSerialNumber(cert.SerialNumber)
-		labels[1] = cert.Subject.String()
-		labels[2] = cert.NotBefore.Format(time.RFC3339)
-		labels[3] = cert.NotAfter.Format(time.RFC3339)
+	ch <- prometheus.MustNewConstMetric(
+		c.crlValid,
+		prometheus.GaugeValue,
+		validity(now, crl.ThisUpdate, crl.NextUpdate),
+		labels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.crlThisUpdate,
+		prometheus.GaugeValue,
+		float64(crl.ThisUpdate.Unix()),
+		labels...,
+	)
+	if !crl.NextUpdate.IsZero() {
 		ch <- prometheus.MustNewConstMetric(
-			c.certValid,
+			c.crlExpireSecs,
 			prometheus.GaugeValue,
-			float64(valid),
+			crl.NextUpdate.Sub(now).Seconds(),
 			labels...,
 		)
 		ch <- prometheus.MustNewConstMetric(
-			c.certExpireSecs,
+			c.crlNextUpdate,
 			prometheus.GaugeValue,
-			expireIn,
+			float64(crl.NextUpdate.Unix()),
 			labels...,
 		)
 	}
+	ch <- prometheus.MustNewConstMetric(
+		c.crlRevokedCnt,
+		prometheus.GaugeValue,
+		float64(len(crl.RevokedCertificateEntries)),
+		labels...,
+	)
+}
+
+func (c *CertsCollector) collectAcMetrics(vc Cert, now time.Time, ch chan<- prometheus.Metric) {
+	ac, err := parseAttributeCert([]byte(vc.Data))
+	if err != nil {
+		log.Logger.Warnf("Attribute certificate parse error: %v", err)
+		return
+	}
+	serialNumber := formatSerialNumber(ac.Info.SerialNumber)
+	ch <- prometheus.MustNewConstMetric(
+		c.acValid,
+		prometheus.GaugeValue,
+		validity(now, ac.Info.Validity.NotBefore, ac.Info.Validity.NotAfter),
+		serialNumber,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.acExpireSecs,
+		prometheus.GaugeValue,
+		ac.Info.Validity.NotAfter.Sub(now).Seconds(),
+		serialNumber,
+	)
 }
 
-func (c *CertsCollector) listCerts() ([]Cert, error) {
-	s, err := c.viciClientFn()
+func (c *CertsCollector) collectOcspMetrics(vc Cert, now time.Time, ch chan<- prometheus.Metric) {
+	responses, err := parseOcspResponses([]byte(vc.Data))
+	if err != nil {
+		log.Logger.Warnf("OCSP response parse error: %v", err)
+		return
+	}
+	for _, r := range responses {
+		serialNumber := formatSerialNumber(r.CertID.SerialNumber)
+		ch <- prometheus.MustNewConstMetric(
+			c.ocspValid,
+			prometheus.GaugeValue,
+			validity(now, r.ThisUpdate, r.NextUpdate),
+			serialNumber,
+		)
+		if !r.NextUpdate.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				c.ocspExpireSecs,
+				prometheus.GaugeValue,
+				r.NextUpdate.Sub(now).Seconds(),
+				serialNumber,
+			)
+		}
+	}
+}
+
+func (c *CertsCollector) collectPubkeyMetrics(vc Cert, now time.Time, ch chan<- prometheus.Metric) {
+	var notBefore, notAfter time.Time
+	var err error
+	if vc.NotBefore != "" {
+		if notBefore, err = parseViciTime(vc.NotBefore, c.loc); err != nil {
+			log.Logger.Warnf("Public key validity parse error: %v", err)
+			return
+		}
+	}
+	if vc.NotAfter != "" {
+		if notAfter, err = parseViciTime(vc.NotAfter, c.loc); err != nil {
+			log.Logger.Warnf("Public key validity parse error: %v", err)
+			return
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.pubkeyValid,
+		prometheus.GaugeValue,
+		validity(now, notBefore, notAfter),
+		vc.Subject,
+	)
+	if !notAfter.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			c.pubkeyExpireSecs,
+			prometheus.GaugeValue,
+			notAfter.Sub(now).Seconds(),
+			vc.Subject,
+		)
+	}
+}
+
+// validity is 1 if now is within the validity period, a zero start or end leaves the period open.
+func validity(now time.Time, notBefore time.Time, notAfter time.Time) float64 {
+	if (!notBefore.IsZero() && now.Before(notBefore)) || (!notAfter.IsZero() && !now.Before(notAfter)) {
+		return 0
+	}
+	return 1
+}
+
+// listCerts lists the loaded certificates of all types, types the exporter does not support are skipped.
+func listCerts(viciClientFn viciClientFn) ([]Cert, error) {
+	s, err := viciClientFn()
 	if err != nil {
 		return nil, err
 	}
 	defer s.Close()
 
 	req := vici.NewMessage()
-	if err := req.Set(keyType, typeX509Cert); err != nil {
+	if err := req.Set(keyType, typeAnyCert); err != nil {
 		return nil, err
 	}
 
@@ -129,14 +577,36 @@ func (c *CertsCollector) listCerts() ([]Cert, error) {
 	if err != nil {
 		return nil, err
 	}
+	return unmarshalCerts(msgs, certTypes...)
+}
 
+// parseX509Certs parses the data of the X509 certificates, other types and certificates which fail to parse are skipped.
+func parseX509Certs(certs []Cert) []*x509.Certificate {
+	parsed := make([]*x509.Certificate, 0, len(certs))
+	for _, cert := range certs {
+		if cert.Type != typeX509Cert {
+			continue
+		}
+		c, err := x509.ParseCertificate([]byte(cert.Data))
+		if err != nil {
+			log.Logger.Warnf("Certificate parse error: %v", err)
+			continue
+		}
+		parsed = append(parsed, c)
+	}
+	return parsed
+}
+
+// unmarshalCerts unmarshals the list-cert messages of the certificate types, other types are skipped.
+func unmarshalCerts(msgs []*vici.Message, types ...string) ([]Cert, error) {
+	var err error
 	certs := make([]Cert, 0, len(msgs))
 	for _, m := range msgs {
 		if err = m.Err(); err != nil {
 			log.Logger.Warnf("Message error: %v", err)
 			return nil, err
 		}
-		if m.Get(keyType) != typeX509Cert {
+		if t, _ := m.Get(keyType).(string); !slices.Contains(types, t) {
 			log.Logger.Debugf("Unknown certificate type: '%v'", m.Get(keyType))
 			continue
 		}
@@ -144,7 +614,7 @@ func (c *CertsCollector) listCerts() ([]Cert, error) {
 		var cert Cert
 		if e := vici.UnmarshalMessage(m, &cert); e != nil {
 			log.Logger.Warnf("Message unmarshal error: %v", e)
-			return nil, err
+			return nil, e
 		}
 
 		certs = append(certs, cert)
@@ -83,7 +83,7 @@ func TestCertsCollector_Metrics(t *testing.T) {
 			wantMetricsHelp:  "Number of X509 certificates",
 			wantMetricsType:  "gauge",
 			wantMetricsValue: 1,
-			wantMetricsCount: 3,
+			wantMetricsCount: 4,
 		},
 		{
 			name:       "two certificates",
@@ -103,7 +103,7 @@ func TestCertsCollector_Metrics(t *testing.T) {
 			wantMetricsHelp:  "Number of X509 certificates",
 			wantMetricsType:  "gauge",
 			wantMetricsValue: 2,
-			wantMetricsCount: 5,
+			wantMetricsCount: 9,
 		},
 		{
 			name:       "valid certificate",
@@ -120,7 +120,7 @@ func TestCertsCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `not_after="2034-03-20T15:01:04Z",not_before="2024-03-20T15:01:04Z",serial_number="63:68:4d:00:11:20:7d:dc",subject="CN=Cyber Root CA,O=Cyber,C=CH"`,
 			wantMetricsValue:  1,
-			wantMetricsCount:  3,
+			wantMetricsCount:  4,
 		},
 		{
 			name:       "expired certificate",
@@ -137,7 +137,7 @@ func TestCertsCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `not_after="2025-10-22T18:59:10Z",not_before="2025-10-21T18:59:10Z",serial_number="d0:a9:1f:a5:00:4f:38:88",subject="CN=expired.example.local"`,
 			wantMetricsValue:  0,
-			wantMetricsCount:  3,
+			wantMetricsCount:  6,
 		},
 		{
 			name:       "certificate validity seconds",
@@ -154,7 +154,7 @@ func TestCertsCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `not_after="2034-03-20T15:01:04Z",not_before="2024-03-20T15:01:04Z",serial_number="63:68:4d:00:11:20:7d:dc",subject="CN=Cyber Root CA,O=Cyber,C=CH"`,
 			wantMetricsValue:  25264,
-			wantMetricsCount:  3,
+			wantMetricsCount:  4,
 		},
 		{
 			name:       "certificate validity seconds (expired)",
@@ -171,7 +171,7 @@ func TestCertsCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `not_after="2025-10-22T18:59:10Z",not_before="2025-10-21T18:59:10Z",serial_number="d0:a9:1f:a5:00:4f:38:88",subject="CN=expired.example.local"`,
 			wantMetricsValue:  -18050,
-			wantMetricsCount:  3,
+			wantMetricsCount:  6,
 		},
 	}
 	for _, tt := range tests {
@@ -180,9 +180,12 @@ func TestCertsCollector_Metrics(t *testing.T) {
 				func() (ViciClient, error) {
 					return &fakeViciClient{certMsgs: tt.msgsGetterFn(), err: tt.viciSessionErr}, tt.viciClientErr
 				},
+				time.Local,
 				func() time.Time {
 					return time.Unix(tt.nowSeconds, 0)
 				},
+				MetricsSchemaV1,
+				nil,
 			)
 
 			cnt := testutil.CollectAndCount(c)
@@ -244,6 +247,16 @@ func TestFormatSerialNumber(t *testing.T) {
 	}
 }
 
+func TestUnmarshalCerts_UnmarshalError(t *testing.T) {
+	msg := vici.NewMessage()
+	msg.Set("type", "X509")
+	msg.Set("subject", []string{"server.strongswan.org"})
+
+	certs, err := unmarshalCerts([]*vici.Message{msg}, typeX509Cert)
+	require.Error(t, err)
+	require.Nil(t, certs)
+}
+
 func loadCert(path string) string {
 	certPEM, err := os.ReadFile(path)
 	if err != nil {
@@ -255,3 +268,356 @@ func loadCert(path string) string {
 	}
 	return string(block.Bytes)
 }
+
+func TestCertsCollector_CertTypes(t *testing.T) {
+	certMsg := func(certType string, path string) *vici.Message {
+		msg := vici.NewMessage()
+		msg.Set("type", certType)
+		msg.Set("data", loadCert(path))
+		return msg
+	}
+	pubkeyMsg := func(notAfter string) *vici.Message {
+		msg := certMsg("PUBKEY", "testdata/pubkey.pem")
+		msg.Set("subject", "server.strongswan.org")
+		if notAfter != "" {
+			msg.Set("not-before", "Mar 20 15:01:04 UTC 2024")
+			msg.Set("not-after", notAfter)
+		}
+		return msg
+	}
+	tests := []struct {
+		name              string
+		nowSeconds        int64
+		msgs              []*vici.Message
+		metricName        string
+		wantMetricsHelp   string
+		wantMetricsLabels string
+		wantMetricsValue  float64
+		wantMetricsCount  int
+	}{
+		{
+			name:             "only X509 certificates are counted",
+			nowSeconds:       1792108800, // 2026-10-16T00:00:00Z
+			msgs:             []*vici.Message{certMsg("X509", "testdata/cert.pem"), certMsg("X509_CRL", "testdata/crl.pem")},
+			metricName:       "swtest_cert_count",
+			wantMetricsHelp:  "Number of X509 certificates",
+			wantMetricsValue: 1,
+			wantMetricsCount: 11,
+		},
+		{
+			name:              "valid CRL",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{certMsg("X509_CRL", "testdata/crl.pem")},
+			metricName:        "swtest_crl_valid",
+			wantMetricsHelp:   "X509 CRL validity, 0 if the next update is overdue",
+			wantMetricsLabels: `crl_number="42",issuer="CN=Cyber Root CA,O=Cyber,C=CH"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  6,
+		},
+		{
+			name:              "overdue CRL",
+			nowSeconds:        1793577600, // 2026-11-02T00:00:00Z
+			msgs:              []*vici.Message{certMsg("X509_CRL", "testdata/crl.pem")},
+			metricName:        "swtest_crl_valid",
+			wantMetricsHelp:   "X509 CRL validity, 0 if the next update is overdue",
+			wantMetricsLabels: `crl_number="42",issuer="CN=Cyber Root CA,O=Cyber,C=CH"`,
+			wantMetricsValue:  0,
+			wantMetricsCount:  6,
+		},
+		{
+			name:              "CRL next update seconds",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{certMsg("X509_CRL", "testdata/crl.pem")},
+			metricName:        "swtest_crl_expire_seconds",
+			wantMetricsHelp:   "Seconds until the next update of the X509 CRL",
+			wantMetricsLabels: `crl_number="42",issuer="CN=Cyber Root CA,O=Cyber,C=CH"`,
+			wantMetricsValue:  1382400,
+			wantMetricsCount:  6,
+		},
+		{
+			name:              "CRL this update",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{certMsg("X509_CRL", "testdata/crl.pem")},
+			metricName:        "swtest_crl_this_update_timestamp_seconds",
+			wantMetricsHelp:   "Issue date of the X509 CRL as Unix timestamp",
+			wantMetricsLabels: `crl_number="42",issuer="CN=Cyber Root CA,O=Cyber,C=CH"`,
+			wantMetricsValue:  1790812800,
+			wantMetricsCount:  6,
+		},
+		{
+			name:              "CRL revoked certificates",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{certMsg("X509_CRL", "testdata/crl.pem")},
+			metricName:        "swtest_crl_revoked_certs",
+			wantMetricsHelp:   "Number of revoked certificates listed in the X509 CRL",
+			wantMetricsLabels: `crl_number="42",issuer="CN=Cyber Root CA,O=Cyber,C=CH"`,
+			wantMetricsValue:  3,
+			wantMetricsCount:  6,
+		},
+		{
+			name:              "attribute certificate expiry",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{certMsg("X509_AC", "testdata/ac.pem")},
+			metricName:        "swtest_ac_expire_seconds",
+			wantMetricsHelp:   "Seconds until the X509 attribute certificate expires",
+			wantMetricsLabels: `serial_number="0a:bc"`,
+			wantMetricsValue:  1296000,
+			wantMetricsCount:  3,
+		},
+		{
+			name:              "valid OCSP response",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{certMsg("OCSP_RESPONSE", "testdata/ocsp-response.pem")},
+			metricName:        "swtest_ocsp_response_valid",
+			wantMetricsHelp:   "OCSP response validity, 0 if the next update is overdue",
+			wantMetricsLabels: `serial_number="76:38:40:b8:25:18:44:0a"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  3,
+		},
+		{
+			name:              "OCSP response next update seconds",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{certMsg("OCSP_RESPONSE", "testdata/ocsp-response.pem")},
+			metricName:        "swtest_ocsp_response_expire_seconds",
+			wantMetricsHelp:   "Seconds until the next update of the OCSP response",
+			wantMetricsLabels: `serial_number="76:38:40:b8:25:18:44:0a"`,
+			wantMetricsValue:  518400,
+			wantMetricsCount:  3,
+		},
+		{
+			name:              "public key expiry",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{pubkeyMsg("Oct 31 00:00:00 UTC 2026")},
+			metricName:        "swtest_pubkey_expire_seconds",
+			wantMetricsHelp:   "Seconds until the raw public key expires",
+			wantMetricsLabels: `subject="server.strongswan.org"`,
+			wantMetricsValue:  1296000,
+			wantMetricsCount:  3,
+		},
+		{
+			name:              "public key without validity",
+			nowSeconds:        1792108800, // 2026-10-16T00:00:00Z
+			msgs:              []*vici.Message{pubkeyMsg("")},
+			metricName:        "swtest_pubkey_valid",
+			wantMetricsHelp:   "Raw public key validity",
+			wantMetricsLabels: `subject="server.strongswan.org"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCertsCollector("swtest_",
+				func() (ViciClient, error) {
+					return &fakeViciClient{certMsgs: tt.msgs}, nil
+				},
+				time.Local,
+				func() time.Time {
+					return time.Unix(tt.nowSeconds, 0)
+				},
+				MetricsSchemaV1,
+				nil,
+			)
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
+
+			wantMetricsContent := fmt.Sprintf(`# HELP %s %s
+# TYPE %s gauge
+%s{%s} %v
+`, tt.metricName, tt.wantMetricsHelp, tt.metricName, tt.metricName, tt.wantMetricsLabels, tt.wantMetricsValue)
+			if err := testutil.CollectAndCompare(c, strings.NewReader(wantMetricsContent), tt.metricName); err != nil {
+				t.Errorf("unexpected collecting result of '%s':\n%s", tt.metricName, err)
+			}
+		})
+	}
+}
+
+func TestCertsCollector_Info(t *testing.T) {
+	msg := vici.NewMessage()
+	msg.Set("type", "X509")
+	msg.Set("flag", "NONE")
+	msg.Set("has_privkey", "yes")
+	msg.Set("data", loadCert("testdata/cert.pem"))
+	tests := []struct {
+		name        string
+		labels      []string
+		wantMetrics string
+	}{
+		{
+			name:   "schema labels",
+			labels: nil,
+			wantMetrics: `# HELP swtest_cert_info X509 certificate metadata and Vici flags
+# TYPE swtest_cert_info gauge
+swtest_cert_info{fingerprint_sha256="93:5d:49:1b:f3:30:31:b9:ee:a5:20:6d:4e:3a:d6:06:f9:ec:9c:72:9f:5a:f7:da:56:ed:1b:b9:9b:c5:b2:f6",flag="NONE",has_privkey="true",issuer="CN=Cyber Root CA,O=Cyber,C=CH",key_algorithm="ECDSA",key_size="384",not_after="2028-03-20T15:01:04Z",not_before="2024-03-20T15:01:04Z",sans="server.strongswan.org",serial_number="76:38:40:b8:25:18:44:0a",signature_algorithm="ECDSA-SHA384",subject="CN=server.strongswan.org,O=Cyber,C=CH"} 1
+# HELP swtest_cert_valid X509 certificate validity
+# TYPE swtest_cert_valid gauge
+swtest_cert_valid{not_after="2028-03-20T15:01:04Z",not_before="2024-03-20T15:01:04Z",serial_number="76:38:40:b8:25:18:44:0a",subject="CN=server.strongswan.org,O=Cyber,C=CH"} 1
+`,
+		},
+		{
+			name:   "serial number, common name and issuer",
+			labels: []string{"issuer", "subject_cn", "serial_number"},
+			wantMetrics: `# HELP swtest_cert_info X509 certificate metadata and Vici flags
+# TYPE swtest_cert_info gauge
+swtest_cert_info{fingerprint_sha256="93:5d:49:1b:f3:30:31:b9:ee:a5:20:6d:4e:3a:d6:06:f9:ec:9c:72:9f:5a:f7:da:56:ed:1b:b9:9b:c5:b2:f6",flag="NONE",has_privkey="true",issuer="CN=Cyber Root CA,O=Cyber,C=CH",key_algorithm="ECDSA",key_size="384",sans="server.strongswan.org",serial_number="76:38:40:b8:25:18:44:0a",signature_algorithm="ECDSA-SHA384",subject_cn="server.strongswan.org"} 1
+# HELP swtest_cert_valid X509 certificate validity
+# TYPE swtest_cert_valid gauge
+swtest_cert_valid{issuer="CN=Cyber Root CA,O=Cyber,C=CH",serial_number="76:38:40:b8:25:18:44:0a",subject_cn="server.strongswan.org"} 1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCertsCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{certMsgs: []*vici.Message{msg}}, nil
+			}, time.Local, func() time.Time {
+				return time.Unix(1792108800, 0) // 2026-10-16T00:00:00Z
+			}, MetricsSchemaV1, tt.labels)
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.wantMetrics), "swtest_cert_info", "swtest_cert_valid"); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
+
+func TestValidateCertLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "ordered labels",
+			labels: []string{"issuer", "subject_cn", "serial_number"},
+			want:   []string{"serial_number", "subject_cn", "issuer"},
+		},
+		{
+			name:   "fingerprint",
+			labels: []string{"subject_cn", "fingerprint_sha256"},
+			want:   []string{"subject_cn", "fingerprint_sha256"},
+		},
+		{
+			name:    "labels not identifying a certificate",
+			labels:  []string{"subject_cn"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown label",
+			labels:  []string{"serial_number", "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "no labels",
+			labels:  []string{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateCertLabels(tt.labels)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCertsCollector_Chain(t *testing.T) {
+	certMsg := func(path string) *vici.Message {
+		msg := vici.NewMessage()
+		msg.Set("type", "X509")
+		msg.Set("data", loadCert(path))
+		return msg
+	}
+	root := certMsg("testdata/chain-root-ca.pem")
+	intermediate := certMsg("testdata/chain-intermediate-ca.pem")
+	leaf := certMsg("testdata/chain-leaf.pem")
+	sha1CA := certMsg("testdata/chain-sha1-ca.pem")
+	sha1Leaf := certMsg("testdata/chain-sha1-leaf.pem")
+	tests := []struct {
+		name        string
+		nowSeconds  int64
+		msgs        []*vici.Message
+		wantMetrics string
+	}{
+		{
+			name:       "valid chain",
+			nowSeconds: 1792108800, // 2026-10-16T00:00:00Z
+			msgs:       []*vici.Message{root, intermediate, leaf},
+			wantMetrics: `# HELP swtest_cert_chain_expire_seconds Seconds until the first certificate in the chain of the X509 end-entity certificate expires
+# TYPE swtest_cert_chain_expire_seconds gauge
+swtest_cert_chain_expire_seconds{serial_number="03:00",subject_cn="gw.chain.example"} 6.6528e+06
+# HELP swtest_cert_chain_valid Validity of the chain of the X509 end-entity certificate up to a loaded CA certificate
+# TYPE swtest_cert_chain_valid gauge
+swtest_cert_chain_valid{serial_number="03:00",subject_cn="gw.chain.example"} 1
+`,
+		},
+		{
+			name:       "expired intermediate CA",
+			nowSeconds: 1798848000, // 2027-01-02T00:00:00Z
+			msgs:       []*vici.Message{root, intermediate, leaf},
+			wantMetrics: `# HELP swtest_cert_chain_expire_seconds Seconds until the first certificate in the chain of the X509 end-entity certificate expires
+# TYPE swtest_cert_chain_expire_seconds gauge
+swtest_cert_chain_expire_seconds{serial_number="03:00",subject_cn="gw.chain.example"} -86400
+# HELP swtest_cert_chain_valid Validity of the chain of the X509 end-entity certificate up to a loaded CA certificate
+# TYPE swtest_cert_chain_valid gauge
+swtest_cert_chain_valid{serial_number="03:00",subject_cn="gw.chain.example"} 0
+`,
+		},
+		{
+			name:       "intermediate CA as trust anchor",
+			nowSeconds: 1792108800, // 2026-10-16T00:00:00Z
+			msgs:       []*vici.Message{intermediate, leaf},
+			wantMetrics: `# HELP swtest_cert_chain_expire_seconds Seconds until the first certificate in the chain of the X509 end-entity certificate expires
+# TYPE swtest_cert_chain_expire_seconds gauge
+swtest_cert_chain_expire_seconds{serial_number="03:00",subject_cn="gw.chain.example"} 6.6528e+06
+# HELP swtest_cert_chain_valid Validity of the chain of the X509 end-entity certificate up to a loaded CA certificate
+# TYPE swtest_cert_chain_valid gauge
+swtest_cert_chain_valid{serial_number="03:00",subject_cn="gw.chain.example"} 1
+`,
+		},
+		{
+			name:       "missing intermediate CA",
+			nowSeconds: 1792108800, // 2026-10-16T00:00:00Z
+			msgs:       []*vici.Message{root, leaf},
+			wantMetrics: `# HELP swtest_cert_chain_expire_seconds Seconds until the first certificate in the chain of the X509 end-entity certificate expires
+# TYPE swtest_cert_chain_expire_seconds gauge
+swtest_cert_chain_expire_seconds{serial_number="03:00",subject_cn="gw.chain.example"} 3.81888e+07
+# HELP swtest_cert_chain_valid Validity of the chain of the X509 end-entity certificate up to a loaded CA certificate
+# TYPE swtest_cert_chain_valid gauge
+swtest_cert_chain_valid{serial_number="03:00",subject_cn="gw.chain.example"} 0
+`,
+		},
+		{
+			name:       "SHA-1 signature",
+			nowSeconds: 1792108800, // 2026-10-16T00:00:00Z
+			msgs:       []*vici.Message{sha1CA, sha1Leaf},
+			wantMetrics: `# HELP swtest_cert_chain_expire_seconds Seconds until the first certificate in the chain of the X509 end-entity certificate expires
+# TYPE swtest_cert_chain_expire_seconds gauge
+swtest_cert_chain_expire_seconds{serial_number="06:00",subject_cn="sha1.chain.example"} 3.81888e+07
+# HELP swtest_cert_chain_valid Validity of the chain of the X509 end-entity certificate up to a loaded CA certificate
+# TYPE swtest_cert_chain_valid gauge
+swtest_cert_chain_valid{serial_number="06:00",subject_cn="sha1.chain.example"} 1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCertsCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{certMsgs: tt.msgs}, nil
+			}, time.Local, func() time.Time {
+				return time.Unix(tt.nowSeconds, 0)
+			}, MetricsSchemaV1, []string{"serial_number", "subject_cn"})
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.wantMetrics), "swtest_cert_chain_valid", "swtest_cert_chain_expire_seconds"); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 )
 
+// Check sends a version request, so the check fails while charon does not respond.
 func (c *Collector) Check(context.Context) error {
 	s, err := c.viciClientFn()
 	if err != nil {
 		return err
 	}
-	_ = s.Close()
-	return nil
+	defer s.Close()
+	_, err = s.CommandRequest("version", nil)
+	return err
 }
@@ -6,12 +6,14 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
 )
 
 func TestCollector_Check(t *testing.T) {
 	tests := []struct {
 		name          string
 		viciClientErr error
+		versionErr    error
 		wantErr       bool
 		wantCloseCall int
 	}{
@@ -27,15 +29,21 @@ func TestCollector_Check(t *testing.T) {
 			wantErr:       true,
 			wantCloseCall: 0,
 		},
+		{
+			name:          "Charon not responding",
+			versionErr:    errors.New("broken pipe"),
+			wantErr:       true,
+			wantCloseCall: 1,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			viciClientFnCalls := 0
-			fvc := &fakeViciClient{}
+			fvc := &fakeViciClient{cmdMsgs: map[string]*vici.Message{"version": vici.NewMessage()}, err: tt.versionErr}
 			c := NewCollector(func() (ViciClient, error) {
 				viciClientFnCalls++
 				return fvc, tt.viciClientErr
-			}, false, false)
+			}, Config{})
 			if err := c.Check(context.TODO()); (err != nil) != tt.wantErr {
 				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
 			}
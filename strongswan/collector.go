@@ -1,6 +1,8 @@
 package strongswan
 
 import (
+	"context"
+	"net/http"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -9,34 +11,213 @@ import (
 )
 
 type ViciClient interface {
+	CommandRequest(cmd string, msg *vici.Message) (*vici.Message, error)
 	StreamedCommandRequest(cmd string, event string, msg *vici.Message) ([]*vici.Message, error)
 	Close() error
 }
 
 type viciClientFn func() (ViciClient, error)
 
+type Config struct {
+	CertMetricsEnabled bool
+	// CertLabels are the labels identifying a certificate on the validity metrics as validated by ValidateCertLabels,
+	// nil for the labels of the metrics schema.
+	CertLabels              []string
+	AuthorityMetricsEnabled bool
+	// CredMetricsEnabled counts the loaded private keys and shared secrets, the secrets are never read.
+	CredMetricsEnabled bool
+	// RevocationMetricsEnabled fetches the CRLs and queries the OCSP responders of the loaded certificates in the
+	// background, which requires Collector.Run.
+	RevocationMetricsEnabled bool
+	RevocationTimeout        time.Duration
+	RevocationCacheTTL       time.Duration
+	// RevocationScope selects the checked certificates, empty for RevocationScopeLocal.
+	RevocationScope RevocationScope
+	// SwanctlDir is the swanctl directory scanned for credential files, empty to disable the scan.
+	SwanctlDir         string
+	ConnMetricsEnabled bool
+	// ConnCertMetricsEnabled maps the loaded certificates to the authentication rounds of the connections.
+	ConnCertMetricsEnabled bool
+	// ConnStatusMetricsEnabled joins the loaded connections with the SAs to report down connections.
+	ConnStatusMetricsEnabled bool
+	// CryptoPolicy is evaluated against the SAs and certificates, nil to disable the evaluation.
+	CryptoPolicy        *CryptoPolicy
+	StatsMetricsEnabled bool
+	// CharonLocation is the time zone of the times printed by charon, nil for the local time zone of the exporter.
+	CharonLocation          *time.Location
+	CounterMetricsEnabled   bool
+	AlgorithmMetricsEnabled bool
+	PoolMetricsEnabled      bool
+	// PoolLeaseMetricsEnabled adds an info metric per lease, mapping the virtual IPs to identities.
+	PoolLeaseMetricsEnabled bool
+	PolicyMetricsEnabled    bool
+	// MetricsSchema selects the metric names and labels, empty for MetricsSchemaV1.
+	MetricsSchema MetricsSchema
+	// IkeInfoLabels are the optional labels of the IKE info metric, nil for DefaultIkeInfoLabels.
+	IkeInfoLabels []string
+
+	SaCacheEnabled           bool
+	SaCacheReconcileInterval time.Duration
+	EventMetricsEnabled      bool
+	TrafficMetricsEnabled    bool
+
+	// Events is the Vici event stream used by the event driven features.
+	Events *EventListener
+}
+
 type Collector struct {
 	viciClientFn viciClientFn
+	saCache      *SasCache
 	cs           []prometheus.Collector
+	// background are the loops started by Run.
+	background []func(context.Context)
 }
 
-func NewCollector(viciClientFn viciClientFn, certMetricsEnabled bool, connMetricsEnabled bool) *Collector {
+func NewCollector(viciClientFn viciClientFn, cfg Config) *Collector {
 	prefix := "strongswan_"
+	schema := cfg.MetricsSchema
+	if schema == "" {
+		schema = MetricsSchemaV1
+	}
+
+	charonLocation := cfg.CharonLocation
+	if charonLocation == nil {
+		charonLocation = time.Local
+	}
+
+	vc := NewVersionCollector(prefix, viciClientFn)
+	var background []func(context.Context)
+
+	var saCache *SasCache
+	if cfg.SaCacheEnabled {
+		if cfg.Events != nil {
+			log.Logger.Info("SA cache enabled.")
+			saCache = NewSasCache(prefix, viciClientFn, cfg.SaCacheReconcileInterval, time.Now)
+			cfg.Events.register(saCache, saCacheEvents...)
+			vc.require("The SA cache (ike-update event)", "5.6.1")
+		} else {
+			log.Logger.Warn("SA cache requires the Vici event stream, falling back to list-sas on every scrape.")
+		}
+	}
+
 	cs := []prometheus.Collector{
-		NewSasCollector(prefix, viciClientFn),
+		vc,
+		NewSasCollector(prefix, viciClientFn, saCache, cfg.IkeInfoLabels, time.Now, schema),
+	}
+	if saCache != nil {
+		cs = append(cs, saCache)
 	}
-	if certMetricsEnabled {
+	if cfg.CertMetricsEnabled {
 		log.Logger.Info("Certificate metrics enabled.")
-		cs = append(cs, NewCertsCollector(prefix, viciClientFn, time.Now))
+		cs = append(cs, NewCertsCollector(prefix, viciClientFn, charonLocation, time.Now, schema, cfg.CertLabels))
+	}
+	if cfg.AuthorityMetricsEnabled {
+		log.Logger.Info("Certification authority metrics enabled.")
+		cs = append(cs, NewAuthoritiesCollector(prefix, viciClientFn, time.Now))
+		vc.require("Certification authority metrics (list-authorities command)", "5.3.3")
+	}
+	if cfg.CredMetricsEnabled {
+		log.Logger.Info("Credential metrics enabled.")
+		cs = append(cs, NewCredsCollector(prefix, viciClientFn, schema))
+		vc.require("Credential metrics (get-keys and get-shared commands)", "5.5.2")
+	}
+	if cfg.RevocationMetricsEnabled {
+		log.Logger.Info("Certificate revocation metrics enabled.")
+		scope := cfg.RevocationScope
+		if scope == "" {
+			scope = RevocationScopeLocal
+		}
+		client := &http.Client{Timeout: cfg.RevocationTimeout}
+		rc := NewRevocationCollector(prefix, viciClientFn, client, cfg.RevocationCacheTTL, scope, time.Now)
+		background = append(background, rc.Run)
+		cs = append(cs, rc)
 	}
-	if connMetricsEnabled {
+	if cfg.SwanctlDir != "" {
+		log.Logger.Infof("Swanctl directory metrics enabled for %s.", cfg.SwanctlDir)
+		cs = append(cs, NewSwanctlDirCollector(prefix, viciClientFn, cfg.SwanctlDir, time.Now))
+	}
+	if cfg.ConnMetricsEnabled {
 		log.Logger.Info("Connection metrics enabled.")
-		cs = append(cs, NewConnsCollector(prefix, viciClientFn))
+		cs = append(cs, NewConnsCollector(prefix, viciClientFn, schema))
+	}
+	if cfg.ConnCertMetricsEnabled {
+		log.Logger.Info("Connection certificate metrics enabled.")
+		cs = append(cs, NewConnCertsCollector(prefix, viciClientFn, time.Now, schema))
+	}
+	if cfg.ConnStatusMetricsEnabled {
+		log.Logger.Info("Connection status metrics enabled.")
+		cs = append(cs, NewConnStatusCollector(prefix, viciClientFn, saCache, schema))
+	}
+	if cfg.CryptoPolicy != nil {
+		log.Logger.Infof("Crypto policy metrics enabled for %s.", cfg.CryptoPolicy.Name)
+		cs = append(cs, NewCryptoPolicyCollector(prefix, viciClientFn, saCache, cfg.CryptoPolicy))
+	}
+	if cfg.StatsMetricsEnabled {
+		log.Logger.Info("Daemon statistics metrics enabled.")
+		cs = append(cs, NewStatsCollector(prefix, viciClientFn, charonLocation, time.Now))
+		vc.require("Daemon statistics metrics (stats command)", "5.2.0")
+	}
+	if cfg.CounterMetricsEnabled {
+		log.Logger.Info("Counter metrics enabled.")
+		cs = append(cs, NewCountersCollector(prefix, viciClientFn, schema))
+		vc.require("Counter metrics (counters plugin)", "5.6.1")
+	}
+	if cfg.AlgorithmMetricsEnabled {
+		log.Logger.Info("Algorithm metrics enabled.")
+		cs = append(cs, NewAlgorithmsCollector(prefix, viciClientFn))
+		vc.require("Algorithm metrics (get-algorithms command)", "5.4.0")
+	}
+	if cfg.PoolMetricsEnabled {
+		log.Logger.Info("Virtual IP pool metrics enabled.")
+		cs = append(cs, NewPoolsCollector(prefix, viciClientFn, cfg.PoolLeaseMetricsEnabled))
+		vc.require("Virtual IP pool metrics (get-pools command)", "5.3.0")
+	}
+	if cfg.PolicyMetricsEnabled {
+		log.Logger.Info("Policy metrics enabled.")
+		cs = append(cs, NewPoliciesCollector(prefix, viciClientFn))
+		vc.require("Policy metrics (list-policies command)", "5.2.0")
+	}
+	if cfg.TrafficMetricsEnabled {
+		log.Logger.Info("Traffic counter metrics enabled.")
+		tc := NewTrafficCollector(prefix, viciClientFn, saCache, time.Now)
+		if saCache != nil {
+			log.Logger.Warn("Traffic counters of live CHILD SAs are only updated by SA cache events and reconciles.")
+		}
+		if cfg.Events != nil {
+			cfg.Events.register(tc, trafficCollectorEvents...)
+			vc.require("Traffic counters of rekeyed CHILD SAs (child-rekey event)", "5.4.0")
+		} else {
+			log.Logger.Warn("Traffic of CHILD SAs deleted between scrapes is not counted without the Vici event stream.")
+		}
+		cs = append(cs, tc)
+	}
+	if cfg.EventMetricsEnabled {
+		if cfg.Events != nil {
+			log.Logger.Info("Event metrics enabled.")
+			ec := NewEventsCollector(prefix)
+			cfg.Events.register(ec, eventsCollectorEvents...)
+			vc.require("Rekey event metrics (ike-rekey and child-rekey events)", "5.4.0")
+			cs = append(cs, ec)
+		} else {
+			log.Logger.Warn("Event metrics require the Vici event stream and are disabled.")
+		}
+	}
+	if cfg.Events != nil && cfg.Events.enabled() {
+		cs = append(cs, cfg.Events)
 	}
 
 	return &Collector{
 		viciClientFn: viciClientFn,
+		saCache:      saCache,
 		cs:           cs,
+		background:   background,
+	}
+}
+
+// Run starts the background loops of the enabled collectors, which stop when the context is canceled.
+func (c *Collector) Run(ctx context.Context) {
+	for _, run := range c.background {
+		go run(ctx)
 	}
 }
 
@@ -46,8 +227,14 @@ func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
 	}
 }
 
+// Collect shares the listings of the scrape between the collectors, so each is requested at most once.
 func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := newScrape(c.viciClientFn, c.saCache)
 	for _, sc := range c.cs {
-		sc.Collect(ch)
+		if scc, ok := sc.(scrapeCollector); ok {
+			scc.collect(ch, s)
+		} else {
+			sc.Collect(ch)
+		}
 	}
 }
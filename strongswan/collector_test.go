@@ -5,9 +5,21 @@ import (
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
 	"github.com/strongswan/govici/vici"
 )
 
+// countingViciClient counts the streamed commands of all sessions.
+type countingViciClient struct {
+	*fakeViciClient
+	requests map[string]int
+}
+
+func (cvc *countingViciClient) StreamedCommandRequest(cmd string, event string, msg *vici.Message) ([]*vici.Message, error) {
+	cvc.requests[cmd]++
+	return cvc.fakeViciClient.StreamedCommandRequest(cmd, event, msg)
+}
+
 func TestCollector_Metrics(t *testing.T) {
 	ikeMsg := vici.NewMessage()
 	ikeMsg.Set("version", 5)
@@ -68,7 +80,7 @@ strongswan_conn_count 0
 		t.Run(tt.name, func(t *testing.T) {
 			c := NewCollector(func() (ViciClient, error) {
 				return &fakeViciClient{saMsgs: []*vici.Message{msgs}}, nil
-			}, tt.certsEnabled, tt.connsEnabled)
+			}, Config{CertMetricsEnabled: tt.certsEnabled, ConnMetricsEnabled: tt.connsEnabled})
 
 			if err := testutil.CollectAndCompare(c, strings.NewReader(wantIKEVersionMetricContent), "strongswan_ike_version"); err != nil {
 				t.Errorf("unexpected collecting result of 'swstrongswan_ike_version':\n%s", err)
@@ -84,3 +96,28 @@ strongswan_conn_count 0
 		})
 	}
 }
+
+func TestCollector_SharedListings(t *testing.T) {
+	cvc := &countingViciClient{
+		fakeViciClient: &fakeViciClient{certMsgs: []*vici.Message{caCertMsg("testdata/revocation-ca.pem")}, cmdMsgs: map[string]*vici.Message{"get-keys": vici.NewMessage(), "get-shared": vici.NewMessage()}},
+		requests:       make(map[string]int),
+	}
+	c := NewCollector(func() (ViciClient, error) {
+		return cvc, nil
+	}, Config{
+		CertMetricsEnabled:       true,
+		AuthorityMetricsEnabled:  true,
+		CredMetricsEnabled:       true,
+		SwanctlDir:               "testdata/swanctl",
+		ConnMetricsEnabled:       true,
+		ConnCertMetricsEnabled:   true,
+		ConnStatusMetricsEnabled: true,
+		CryptoPolicy:             &CryptoPolicy{Name: "test"},
+		TrafficMetricsEnabled:    true,
+	})
+
+	testutil.CollectAndCount(c)
+	require.Equal(t, 1, cvc.requests["list-sas"], "list-sas requests")
+	require.Equal(t, 1, cvc.requests["list-conns"], "list-conns requests")
+	require.Equal(t, 1, cvc.requests["list-certs"], "list-certs requests")
+}
@@ -0,0 +1,120 @@
+package strongswan
+
+import (
+	"crypto/x509"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+ConnCertsCollector maps the loaded X509 certificates to the authentication rounds of the loaded connections
+using them, so an expiring certificate names the affected connections. A round uses the certificates and
+CA certificates configured by their subject, or the end-entity certificates of its identity if no
+certificate is configured.
+*/
+type ConnCertsCollector struct {
+	viciClientFn viciClientFn
+	now          func() time.Time
+
+	connCertExpireSecs *prometheus.Desc
+}
+
+func NewConnCertsCollector(prefix string, viciClientFn viciClientFn, now func() time.Time, schema MetricsSchema) prometheus.Collector {
+	return &ConnCertsCollector{
+		viciClientFn: viciClientFn,
+		now:          now,
+
+		connCertExpireSecs: prometheus.NewDesc(
+			prefix+"conn_cert_expire_seconds",
+			"Seconds until the X509 certificate used by the authentication round of the connection expires",
+			schema.labels([]string{"conn_name", "auth_round", "serial_number"}, []string{"ike_name", "auth_round", "serial_number"}), nil,
+		),
+	}
+}
+
+func (c *ConnCertsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connCertExpireSecs
+}
+
+func (c *ConnCertsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, newScrape(c.viciClientFn, nil))
+}
+
+func (c *ConnCertsCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	conns, err := s.listConns()
+	if err != nil {
+		return
+	}
+	certs, err := s.listX509Certs()
+	if err != nil {
+		return
+	}
+	now := c.now()
+	for _, conn := range conns {
+		c.collectAuthMetrics(conn.Name, "local-", conn.LocalAuths, certs, now, ch)
+		c.collectAuthMetrics(conn.Name, "remote-", conn.RemoteAuths, certs, now, ch)
+	}
+}
+
+func (c *ConnCertsCollector) collectAuthMetrics(connName string, prefix string, auths []ConnAuth, certs []*x509.Certificate, now time.Time, ch chan<- prometheus.Metric) {
+	for _, auth := range auths {
+		serialNumbers := make(map[string]bool)
+		for _, cert := range authCerts(auth, certs) {
+			serialNumber := formatSerialNumber(cert.SerialNumber)
+			if serialNumbers[serialNumber] {
+				continue
+			}
+			serialNumbers[serialNumber] = true
+			ch <- prometheus.MustNewConstMetric(
+				c.connCertExpireSecs,
+				prometheus.GaugeValue,
+				cert.NotAfter.Sub(now).Seconds(),
+				connName, prefix+auth.Round, serialNumber,
+			)
+		}
+	}
+}
+
+/*
+authCerts returns the loaded certificates used by the authentication round. Without configured certs, e.g. in a
+remote round with cacerts only, the end-entity certificates of the identity are used next to the CA certificates,
+as the peer certificate cached by charon is the one the round authenticates.
+*/
+func authCerts(auth ConnAuth, certs []*x509.Certificate) []*x509.Certificate {
+	var used []*x509.Certificate
+	for _, subject := range slices.Concat(auth.Certs, auth.CaCerts) {
+		for _, cert := range certs {
+			if hasSubject(cert, subject) {
+				used = append(used, cert)
+			}
+		}
+	}
+	if len(auth.Certs) > 0 {
+		return used
+	}
+	for _, cert := range certs {
+		if !cert.IsCA && hasIdentity(cert, auth.ID) {
+			used = append(used, cert)
+		}
+	}
+	return used
+}
+
+// hasIdentity reports if the identity is the subject or one of the subject alternative names of the certificate.
+func hasIdentity(cert *x509.Certificate, id string) bool {
+	if id == "" || id == "%any" {
+		return false
+	}
+	if hasSubject(cert, id) {
+		return true
+	}
+	if slices.Contains(cert.DNSNames, id) || slices.Contains(cert.EmailAddresses, id) {
+		return true
+	}
+	return slices.ContainsFunc(cert.IPAddresses, func(ip net.IP) bool {
+		return ip.String() == id
+	})
+}
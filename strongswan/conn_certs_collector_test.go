@@ -0,0 +1,96 @@
+package strongswan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func TestConnCertsCollector_Metrics(t *testing.T) {
+	serverSubject := "C=CH, O=Cyber, CN=server.strongswan.org"
+	caSubject := "C=CH, O=Cyber, CN=Cyber Root CA"
+	gwConnMsg := func(local *vici.Message, remote *vici.Message) *vici.Message {
+		return connsMsg("gw", withAuth(withAuth(connMsg(), "local-1", local), "remote-1", remote))
+	}
+	certMsgs := []*vici.Message{x509CertMsg("testdata/cert-ca.pem"), x509CertMsg("testdata/cert.pem")}
+	tests := []struct {
+		name             string
+		viciClientErr    error
+		connMsgs         []*vici.Message
+		wantMetrics      string
+		wantMetricsCount int
+	}{
+		{
+			name:             "connection error",
+			viciClientErr:    errors.New("some error"),
+			wantMetricsCount: 0,
+		},
+		{
+			name: "configured certificates",
+			connMsgs: []*vici.Message{gwConnMsg(
+				authMsg("public key", "server.strongswan.org", []string{serverSubject}, nil),
+				authMsg("public key", "%any", nil, []string{caSubject}),
+			)},
+			wantMetrics: `# HELP swtest_conn_cert_expire_seconds Seconds until the X509 certificate used by the authentication round of the connection expires
+# TYPE swtest_conn_cert_expire_seconds gauge
+swtest_conn_cert_expire_seconds{auth_round="local-1",conn_name="gw",serial_number="76:38:40:b8:25:18:44:0a"} 4.5068464e+07
+swtest_conn_cert_expire_seconds{auth_round="remote-1",conn_name="gw",serial_number="63:68:4d:00:11:20:7d:dc"} 2.34370864e+08
+`,
+			wantMetricsCount: 2,
+		},
+		{
+			name: "certificate of the identity",
+			connMsgs: []*vici.Message{gwConnMsg(
+				authMsg("public key", "server.strongswan.org", nil, nil),
+				authMsg("public key", "%any", nil, nil),
+			)},
+			wantMetrics: `# HELP swtest_conn_cert_expire_seconds Seconds until the X509 certificate used by the authentication round of the connection expires
+# TYPE swtest_conn_cert_expire_seconds gauge
+swtest_conn_cert_expire_seconds{auth_round="local-1",conn_name="gw",serial_number="76:38:40:b8:25:18:44:0a"} 4.5068464e+07
+`,
+			wantMetricsCount: 1,
+		},
+		{
+			name: "CA certificates and certificate of the identity",
+			connMsgs: []*vici.Message{gwConnMsg(
+				authMsg("public key", "%any", nil, nil),
+				authMsg("public key", "server.strongswan.org", nil, []string{caSubject}),
+			)},
+			wantMetrics: `# HELP swtest_conn_cert_expire_seconds Seconds until the X509 certificate used by the authentication round of the connection expires
+# TYPE swtest_conn_cert_expire_seconds gauge
+swtest_conn_cert_expire_seconds{auth_round="remote-1",conn_name="gw",serial_number="63:68:4d:00:11:20:7d:dc"} 2.34370864e+08
+swtest_conn_cert_expire_seconds{auth_round="remote-1",conn_name="gw",serial_number="76:38:40:b8:25:18:44:0a"} 4.5068464e+07
+`,
+			wantMetricsCount: 2,
+		},
+		{
+			name: "certificate not loaded",
+			connMsgs: []*vici.Message{gwConnMsg(
+				authMsg("public key", "moon.strongswan.org", []string{"C=CH, O=Cyber, CN=moon.strongswan.org"}, nil),
+				authMsg("public key", "%any", nil, nil),
+			)},
+			wantMetricsCount: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConnCertsCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{connMsgs: tt.connMsgs, certMsgs: certMsgs}, tt.viciClientErr
+			}, func() time.Time {
+				return time.Unix(1792108800, 0) // 2026-10-16T00:00:00Z
+			}, MetricsSchemaV1)
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.wantMetrics), "swtest_conn_cert_expire_seconds"); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package strongswan
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+ConnStatusCollector joins the loaded connections with the IKE and CHILD SAs, so that a connection or
+CHILD_SA configuration without any established SA is reported as down instead of being absent.
+*/
+type ConnStatusCollector struct {
+	viciClientFn viciClientFn
+	cache        *SasCache
+
+	connUp      *prometheus.Desc
+	connChildUp *prometheus.Desc
+}
+
+func NewConnStatusCollector(prefix string, viciClientFn viciClientFn, cache *SasCache, schema MetricsSchema) prometheus.Collector {
+	return &ConnStatusCollector{
+		viciClientFn: viciClientFn,
+		cache:        cache,
+
+		connUp: prometheus.NewDesc(
+			prefix+"conn_up",
+			"Flag if the connection has an established IKE SA",
+			schema.labels([]string{"conn_name"}, []string{"ike_name"}), nil,
+		),
+		connChildUp: prometheus.NewDesc(
+			prefix+"conn_child_up",
+			"Flag if the CHILD_SA configuration has an installed CHILD SA",
+			schema.labels([]string{"conn_name", "child_name"}, []string{"ike_name", "child_name"}), nil,
+		),
+	}
+}
+
+func (c *ConnStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connUp
+	ch <- c.connChildUp
+}
+
+func (c *ConnStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, newScrape(c.viciClientFn, c.cache))
+}
+
+func (c *ConnStatusCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	conns, err := s.listConns()
+	if err != nil {
+		return
+	}
+	sas, err := s.listSas()
+	if err != nil {
+		return
+	}
+
+	ikeUp := make(map[string]bool)
+	childUp := make(map[[2]string]bool)
+	for _, ikeSa := range sas {
+		if !isIkeUp(ikeSa.State) {
+			continue
+		}
+		ikeUp[ikeSa.Name] = true
+		for _, child := range ikeSa.Children {
+			if isChildUp(child.State) {
+				childUp[[2]string{ikeSa.Name, child.Name}] = true
+			}
+		}
+	}
+
+	for _, conn := range conns {
+		ch <- prometheus.MustNewConstMetric(
+			c.connUp,
+			prometheus.GaugeValue,
+			boolToFloat(ikeUp[conn.Name]),
+			conn.Name,
+		)
+		for childName := range conn.Children {
+			ch <- prometheus.MustNewConstMetric(
+				c.connChildUp,
+				prometheus.GaugeValue,
+				boolToFloat(childUp[[2]string{conn.Name, childName}]),
+				conn.Name, childName,
+			)
+		}
+	}
+}
+
+func isIkeUp(state string) bool {
+	switch state {
+	case "ESTABLISHED", "REKEYING", "REKEYED":
+		return true
+	default:
+		return false
+	}
+}
+
+func isChildUp(state string) bool {
+	switch state {
+	case "INSTALLED", "UPDATING", "REKEYING", "REKEYED":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package strongswan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func TestConnStatusCollector_Metrics(t *testing.T) {
+	conns := vici.NewMessage()
+	conns.Set("home", connMsg("net", "voip"))
+	conns.Set("office", connMsg("lan"))
+	conns.Set("backup", connMsg("net"))
+
+	sas := vici.NewMessage()
+	sas.Set("home", ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{
+		"net-1":  childSaMsg("net", "1", "INSTALLED"),
+		"voip-2": childSaMsg("voip", "2", "INSTALLING"),
+	}))
+	sas.Set("backup", ikeSaMsg("2", "CONNECTING", nil))
+
+	tests := []struct {
+		name          string
+		viciClientErr error
+		want          string
+		wantCount     int
+	}{
+		{
+			name:          "connection error",
+			viciClientErr: errors.New("some error"),
+			wantCount:     0,
+		},
+		{
+			name: "joined connections and SAs",
+			want: `# HELP swtest_conn_child_up Flag if the CHILD_SA configuration has an installed CHILD SA
+# TYPE swtest_conn_child_up gauge
+swtest_conn_child_up{child_name="lan",conn_name="office"} 0
+swtest_conn_child_up{child_name="net",conn_name="backup"} 0
+swtest_conn_child_up{child_name="net",conn_name="home"} 1
+swtest_conn_child_up{child_name="voip",conn_name="home"} 0
+# HELP swtest_conn_up Flag if the connection has an established IKE SA
+# TYPE swtest_conn_up gauge
+swtest_conn_up{conn_name="backup"} 0
+swtest_conn_up{conn_name="home"} 1
+swtest_conn_up{conn_name="office"} 0
+`,
+			wantCount: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConnStatusCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{connMsgs: []*vici.Message{conns}, saMsgs: []*vici.Message{sas}}, tt.viciClientErr
+			}, nil, MetricsSchemaV1)
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantCount, cnt, "metrics count")
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want)); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
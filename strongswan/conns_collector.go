@@ -1,6 +1,8 @@
 package strongswan
 
 import (
+	"strings"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/strongswan/govici/vici"
 	"github.com/torilabs/ipsec-prometheus-exporter/log"
@@ -19,7 +21,7 @@ type ConnsCollector struct {
 	connChildRekeyPackets *prometheus.Desc
 }
 
-func NewConnsCollector(prefix string, viciClientFn viciClientFn) prometheus.Collector {
+func NewConnsCollector(prefix string, viciClientFn viciClientFn, schema MetricsSchema) prometheus.Collector {
 	return &ConnsCollector{
 		viciClientFn: viciClientFn,
 
@@ -31,37 +33,37 @@ func NewConnsCollector(prefix string, viciClientFn viciClientFn) prometheus.Coll
 		connVersion: prometheus.NewDesc(
 			prefix+"conn_version",
 			"IKE version for connection",
-			[]string{"conn_name", "version"}, nil,
+			schema.labels([]string{"conn_name", "version"}, []string{"ike_name", "version"}), nil,
 		),
 		connReauthTime: prometheus.NewDesc(
-			prefix+"conn_reauth_time",
+			prefix+schema.name("conn_reauth_time", "conn_reauth_interval_seconds"),
 			"IKE_SA reauthentication interval in seconds",
-			[]string{"conn_name"}, nil,
+			schema.labels([]string{"conn_name"}, []string{"ike_name"}), nil,
 		),
 		connRekeyTime: prometheus.NewDesc(
-			prefix+"conn_rekey_time",
+			prefix+schema.name("conn_rekey_time", "conn_rekey_interval_seconds"),
 			"IKE_SA rekeying interval in seconds",
-			[]string{"conn_name"}, nil,
+			schema.labels([]string{"conn_name"}, []string{"ike_name"}), nil,
 		),
 		connChildCnt: prometheus.NewDesc(
 			prefix+"conn_child_count",
 			"Number of CHILD_SA configurations",
-			[]string{"conn_name"}, nil,
+			schema.labels([]string{"conn_name"}, []string{"ike_name"}), nil,
 		),
 		connChildRekeyTime: prometheus.NewDesc(
-			prefix+"conn_child_rekey_time",
+			prefix+schema.name("conn_child_rekey_time", "conn_child_rekey_interval_seconds"),
 			"CHILD_SA rekeying interval in seconds",
-			[]string{"conn_name", "child_name", "mode"}, nil,
+			schema.labels([]string{"conn_name", "child_name", "mode"}, []string{"ike_name", "child_name", "mode"}), nil,
 		),
 		connChildRekeyBytes: prometheus.NewDesc(
-			prefix+"conn_child_rekey_bytes",
+			prefix+schema.name("conn_child_rekey_bytes", "conn_child_rekey_interval_bytes"),
 			"CHILD_SA rekeying interval in bytes",
-			[]string{"conn_name", "child_name", "mode"}, nil,
+			schema.labels([]string{"conn_name", "child_name", "mode"}, []string{"ike_name", "child_name", "mode"}), nil,
 		),
 		connChildRekeyPackets: prometheus.NewDesc(
-			prefix+"conn_child_rekey_packets",
+			prefix+schema.name("conn_child_rekey_packets", "conn_child_rekey_interval_packets"),
 			"CHILD_SA rekeying interval in packets",
-			[]string{"conn_name", "child_name", "mode"}, nil,
+			schema.labels([]string{"conn_name", "child_name", "mode"}, []string{"ike_name", "child_name", "mode"}), nil,
 		),
 	}
 }
@@ -78,7 +80,11 @@ func (c *ConnsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *ConnsCollector) Collect(ch chan<- prometheus.Metric) {
-	conns, err := c.listConns()
+	c.collect(ch, newScrape(c.viciClientFn, nil))
+}
+
+func (c *ConnsCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	conns, err := s.listConns()
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(
 			c.connCnt,
@@ -169,8 +175,8 @@ func (c *ConnsCollector) collectConnMetrics(conns []Conn, ch chan<- prometheus.M
 	}
 }
 
-func (c *ConnsCollector) listConns() ([]Conn, error) {
-	s, err := c.viciClientFn()
+func listConns(viciClientFn viciClientFn) ([]Conn, error) {
+	s, err := viciClientFn()
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +206,8 @@ func (c *ConnsCollector) listConns() ([]Conn, error) {
 				continue
 			}
 			conn.Name = key
+			conn.LocalAuths = connAuths(connMsg, "local-")
+			conn.RemoteAuths = connAuths(connMsg, "remote-")
 
 			conns = append(conns, conn)
 		}
@@ -207,3 +215,26 @@ func (c *ConnsCollector) listConns() ([]Conn, error) {
 
 	return conns, nil
 }
+
+// connAuths unmarshals the local or remote authentication rounds, which are listed as e.g. local-1, local-2.
+func connAuths(connMsg *vici.Message, prefix string) []ConnAuth {
+	var auths []ConnAuth
+	for _, key := range connMsg.Keys() {
+		round, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		authMsg, ok := connMsg.Get(key).(*vici.Message)
+		if !ok {
+			continue
+		}
+		var auth ConnAuth
+		if e := vici.UnmarshalMessage(authMsg, &auth); e != nil {
+			log.Logger.Warnf("Message unmarshal error: %v", e)
+			continue
+		}
+		auth.Round = round
+		auths = append(auths, auth)
+	}
+	return auths
+}
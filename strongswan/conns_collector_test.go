@@ -11,6 +11,47 @@ import (
 	"github.com/strongswan/govici/vici"
 )
 
+// connMsg is the list-conns section of an IKEv2 connection with the given CHILD_SA configurations.
+func connMsg(children ...string) *vici.Message {
+	m := vici.NewMessage()
+	m.Set("version", "IKEv2")
+	childrenMsg := vici.NewMessage()
+	for _, child := range children {
+		childMsg := vici.NewMessage()
+		childMsg.Set("mode", "TUNNEL")
+		childrenMsg.Set(child, childMsg)
+	}
+	m.Set("children", childrenMsg)
+	return m
+}
+
+// withAuth adds the authentication round, e.g. local-1 or remote-1, to the connection section.
+func withAuth(conn *vici.Message, round string, auth *vici.Message) *vici.Message {
+	conn.Set(round, auth)
+	return conn
+}
+
+// authMsg is an authentication round of a connection.
+func authMsg(class string, id string, certs []string, cacerts []string) *vici.Message {
+	auth := vici.NewMessage()
+	auth.Set("class", class)
+	auth.Set("id", id)
+	if len(certs) > 0 {
+		auth.Set("certs", certs)
+	}
+	if len(cacerts) > 0 {
+		auth.Set("cacerts", cacerts)
+	}
+	return auth
+}
+
+// connsMsg is the list-conn event of the connection.
+func connsMsg(name string, conn *vici.Message) *vici.Message {
+	m := vici.NewMessage()
+	m.Set(name, conn)
+	return m
+}
+
 func TestConnsCollector_Metrics(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -241,6 +282,7 @@ func TestConnsCollector_Metrics(t *testing.T) {
 				func() (ViciClient, error) {
 					return &fakeViciClient{connMsgs: tt.msgsGetterFn(), err: tt.viciSessionErr}, tt.viciClientErr
 				},
+				MetricsSchemaV1,
 			)
 
 			cnt := testutil.CollectAndCount(c)
@@ -0,0 +1,115 @@
+package strongswan
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+// countersGlobalConn is the connection name label of the global counters, which charon reports without a name.
+const countersGlobalConn = "all"
+
+/*
+CountersCollector exposes the IKE counters of the counters plugin, globally and per connection. The
+get-counters command is only known to charon if the plugin is loaded, which is exported as a separate
+metric.
+*/
+type CountersCollector struct {
+	viciClientFn viciClientFn
+
+	pluginLoaded *prometheus.Desc
+	counter      *prometheus.Desc
+}
+
+func NewCountersCollector(prefix string, viciClientFn viciClientFn, schema MetricsSchema) prometheus.Collector {
+	return &CountersCollector{
+		viciClientFn: viciClientFn,
+
+		pluginLoaded: prometheus.NewDesc(
+			prefix+"counters_plugin_loaded",
+			"Flag if the counters plugin is loaded",
+			nil, nil,
+		),
+		counter: prometheus.NewDesc(
+			prefix+"counters_total",
+			"IKE counters of the counters plugin, the connection name is all for the global counters",
+			schema.labels([]string{"conn_name", "counter"}, []string{"ike_name", "counter"}), nil,
+		),
+	}
+}
+
+func (c *CountersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pluginLoaded
+	ch <- c.counter
+}
+
+func (c *CountersCollector) Collect(ch chan<- prometheus.Metric) {
+	counters, err := c.counters()
+	if err != nil {
+		if errors.Is(err, errUnknownCommand) {
+			ch <- prometheus.MustNewConstMetric(
+				c.pluginLoaded,
+				prometheus.GaugeValue,
+				0,
+			)
+		}
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.pluginLoaded,
+		prometheus.GaugeValue,
+		1,
+	)
+	for name, values := range counters {
+		if name == "" {
+			name = countersGlobalConn
+		}
+		for counter, v := range values {
+			ch <- prometheus.MustNewConstMetric(
+				c.counter,
+				prometheus.CounterValue,
+				float64(v),
+				name, counter,
+			)
+		}
+	}
+}
+
+// counters returns the counter values by connection name, the global counters have an empty name.
+func (c *CountersCollector) counters() (map[string]map[string]uint64, error) {
+	s, err := c.viciClientFn()
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	req := vici.NewMessage()
+	if err := req.Set("all", "yes"); err != nil {
+		return nil, err
+	}
+	m, err := s.CommandRequest("get-counters", req)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := make(map[string]map[string]uint64)
+	countersMsg, ok := m.Get("counters").(*vici.Message)
+	if !ok {
+		return counters, nil
+	}
+	for _, name := range countersMsg.Keys() {
+		connMsg, ok := countersMsg.Get(name).(*vici.Message)
+		if !ok {
+			continue
+		}
+		values := make(map[string]uint64)
+		if e := vici.UnmarshalMessage(connMsg, values); e != nil {
+			log.Logger.Warnf("Message unmarshal error: %v", e)
+			continue
+		}
+		counters[name] = values
+	}
+	return counters, nil
+}
@@ -0,0 +1,74 @@
+package strongswan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func countersMsg(conns map[string]map[string]int) *vici.Message {
+	counters := vici.NewMessage()
+	for name, values := range conns {
+		m := vici.NewMessage()
+		for k, v := range values {
+			m.Set(k, v)
+		}
+		counters.Set(name, m)
+	}
+	m := vici.NewMessage()
+	m.Set("counters", counters)
+	m.Set("success", "yes")
+	return m
+}
+
+func TestCountersCollector_Metrics(t *testing.T) {
+	msg := countersMsg(map[string]map[string]int{
+		"":     {"ike-init-in-req": 12, "invalid-spi": 1},
+		"home": {"ike-init-in-req": 3, "child-rekey": 7},
+	})
+	c := NewCountersCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{cmdMsgs: map[string]*vici.Message{"get-counters": msg}}, nil
+	}, MetricsSchemaV1)
+
+	want := `# HELP swtest_counters_plugin_loaded Flag if the counters plugin is loaded
+# TYPE swtest_counters_plugin_loaded gauge
+swtest_counters_plugin_loaded 1
+# HELP swtest_counters_total IKE counters of the counters plugin, the connection name is all for the global counters
+# TYPE swtest_counters_total counter
+swtest_counters_total{conn_name="all",counter="ike-init-in-req"} 12
+swtest_counters_total{conn_name="all",counter="invalid-spi"} 1
+swtest_counters_total{conn_name="home",counter="child-rekey"} 7
+swtest_counters_total{conn_name="home",counter="ike-init-in-req"} 3
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestCountersCollector_PluginNotLoaded(t *testing.T) {
+	c := NewCountersCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{
+			cmdMsgs: map[string]*vici.Message{"get-counters": nil},
+			err:     errUnknownCommand,
+		}, nil
+	}, MetricsSchemaV1)
+
+	want := `# HELP swtest_counters_plugin_loaded Flag if the counters plugin is loaded
+# TYPE swtest_counters_plugin_loaded gauge
+swtest_counters_plugin_loaded 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestCountersCollector_ConnectionError(t *testing.T) {
+	c := NewCountersCollector("swtest_", func() (ViciClient, error) {
+		return nil, errors.New("some error")
+	}, MetricsSchemaV1)
+	require.Equal(t, 0, testutil.CollectAndCount(c), "metrics count")
+}
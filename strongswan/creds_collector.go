@@ -0,0 +1,231 @@
+package strongswan
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+const (
+	authClassPubkey = "public key"
+	authClassPsk    = "pre-shared key"
+	authClassEap    = "EAP"
+	authClassXauth  = "XAuth"
+
+	credentialLoaded  = "loaded"
+	credentialMissing = "missing"
+	credentialUnknown = "unknown"
+)
+
+var credentialStates = []string{credentialLoaded, credentialMissing, credentialUnknown}
+
+// creds are the identifiers of the loaded credentials, the secrets themselves are never requested.
+type creds struct {
+	privateKeys map[string]bool
+	sharedKeys  []string
+	certs       []*x509.Certificate
+}
+
+/*
+CredsCollector exposes the number of private keys and shared secrets loaded via Vici and checks for
+every local authentication round of the loaded connections if a matching credential is loaded. Private
+keys are matched by the key identifier of the certificates of the round, or of the certificates with the
+identity of the round if none is configured. Shared secrets are only known by their unique identifiers,
+not by their owners, so the state of a round using a pre-shared key, EAP or XAuth is unknown unless no
+shared secret is loaded at all.
+*/
+type CredsCollector struct {
+	viciClientFn viciClientFn
+
+	privateKeyCnt *prometheus.Desc
+	sharedKeyCnt  *prometheus.Desc
+	connCredState *prometheus.Desc
+}
+
+func NewCredsCollector(prefix string, viciClientFn viciClientFn, schema MetricsSchema) prometheus.Collector {
+	return &CredsCollector{
+		viciClientFn: viciClientFn,
+
+		privateKeyCnt: prometheus.NewDesc(
+			prefix+"cred_private_keys",
+			"Number of loaded private keys",
+			nil, nil,
+		),
+		sharedKeyCnt: prometheus.NewDesc(
+			prefix+"cred_shared_keys",
+			"Number of loaded shared secrets",
+			nil, nil,
+		),
+		connCredState: prometheus.NewDesc(
+			prefix+"conn_local_auth_credential_state",
+			"State (loaded, missing, unknown) of the credential for the local authentication round of the connection",
+			schema.labels([]string{"conn_name", "auth_round", "auth_class", "state"}, []string{"ike_name", "auth_round", "auth_class", "state"}), nil,
+		),
+	}
+}
+
+func (c *CredsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.privateKeyCnt
+	ch <- c.sharedKeyCnt
+	ch <- c.connCredState
+}
+
+func (c *CredsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, newScrape(c.viciClientFn, nil))
+}
+
+func (c *CredsCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	cr, err := c.creds(s)
+	if err != nil {
+		cr = creds{}
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.privateKeyCnt,
+		prometheus.GaugeValue,
+		float64(len(cr.privateKeys)),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.sharedKeyCnt,
+		prometheus.GaugeValue,
+		float64(len(cr.sharedKeys)),
+	)
+	if err != nil {
+		return
+	}
+
+	conns, err := s.listConns()
+	if err != nil {
+		return
+	}
+	for _, conn := range conns {
+		for _, auth := range conn.LocalAuths {
+			state, ok := cr.credentialState(auth)
+			if !ok {
+				continue
+			}
+			for _, s := range credentialStates {
+				v := 0
+				if s == state {
+					v = 1
+				}
+				ch <- prometheus.MustNewConstMetric(
+					c.connCredState,
+					prometheus.GaugeValue,
+					float64(v),
+					conn.Name, auth.Round, auth.Class, s,
+				)
+			}
+		}
+	}
+}
+
+// credentialState returns the state of the credential of the authentication round, ok is false for classes without credentials.
+func (cr creds) credentialState(auth ConnAuth) (state string, ok bool) {
+	switch auth.Class {
+	case authClassPubkey:
+		var certs []*x509.Certificate
+		for _, subject := range auth.Certs {
+			if cert := findCert(cr.certs, subject); cert != nil {
+				certs = append(certs, cert)
+			}
+		}
+		if len(auth.Certs) == 0 {
+			for _, cert := range cr.certs {
+				if !cert.IsCA && hasIdentity(cert, auth.ID) {
+					certs = append(certs, cert)
+				}
+			}
+		}
+		if len(certs) == 0 {
+			return credentialUnknown, true
+		}
+		for _, cert := range certs {
+			if cr.privateKeys[keyID(cert)] {
+				return credentialLoaded, true
+			}
+		}
+		return credentialMissing, true
+	case authClassPsk, authClassEap, authClassXauth:
+		if len(cr.sharedKeys) == 0 {
+			return credentialMissing, true
+		}
+		return credentialUnknown, true
+	default:
+		return "", false
+	}
+}
+
+func (c *CredsCollector) creds(sc *scrape) (creds, error) {
+	var cr creds
+	s, err := c.viciClientFn()
+	if err != nil {
+		return cr, err
+	}
+	defer s.Close()
+
+	var keys, shared CredKeys
+	if err := commandRequest(s, "get-keys", &keys); err != nil {
+		return cr, err
+	}
+	if err := commandRequest(s, "get-shared", &shared); err != nil {
+		return cr, err
+	}
+
+	certs, err := sc.listX509Certs()
+	if err != nil {
+		return cr, err
+	}
+
+	cr.privateKeys = make(map[string]bool, len(keys.Keys))
+	for _, k := range keys.Keys {
+		cr.privateKeys[k] = true
+	}
+	cr.sharedKeys = shared.Keys
+	cr.certs = certs
+	return cr, nil
+}
+
+func commandRequest(s ViciClient, cmd string, v any) error {
+	m, err := s.CommandRequest(cmd, vici.NewMessage())
+	if err != nil {
+		return err
+	}
+	if err = vici.UnmarshalMessage(m, v); err != nil {
+		log.Logger.Warnf("Message unmarshal error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// keyID is the SHA-1 hash of the public key of the certificate, which charon uses to identify the private keys.
+func keyID(cert *x509.Certificate) string {
+	return publicKeyID(cert.RawSubjectPublicKeyInfo)
+}
+
+// publicKeyID is the SHA-1 hash of the public key of the DER encoded subjectPublicKeyInfo.
+func publicKeyID(der []byte) string {
+	bits, err := publicKeyBits(der)
+	if err != nil {
+		return ""
+	}
+	h := sha1.Sum(bits)
+	return hex.EncodeToString(h[:])
+}
+
+// publicKeyBits returns the encoded public key of the DER encoded subjectPublicKeyInfo.
+func publicKeyBits(der []byte) ([]byte, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+	return spki.PublicKey.Bytes, nil
+}
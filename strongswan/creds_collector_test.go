@@ -0,0 +1,219 @@
+package strongswan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func credKeysMsg(keys ...string) *vici.Message {
+	m := vici.NewMessage()
+	if len(keys) > 0 {
+		m.Set("keys", keys)
+	}
+	return m
+}
+
+func x509CertMsg(path string) *vici.Message {
+	m := vici.NewMessage()
+	m.Set("type", "X509")
+	m.Set("data", loadCert(path))
+	return m
+}
+
+// ownCertMsg is a list-certs event of a certificate with a loaded private key.
+func ownCertMsg(path string) *vici.Message {
+	m := x509CertMsg(path)
+	m.Set("has_privkey", "yes")
+	return m
+}
+
+// localAuthConnMsg is a list-conn event of a connection with a single local authentication round.
+func localAuthConnMsg(name string, class string, certs ...string) *vici.Message {
+	return connsMsg(name, withAuth(connMsg(), "local-1", authMsg(class, "server.strongswan.org", certs, nil)))
+}
+
+func TestCredsCollector_Metrics(t *testing.T) {
+	serverKeyID := "f0ccd31f81afd8d3f57588f0451b417b8ea88df2"
+	serverSubject := "C=CH, O=Cyber, CN=server.strongswan.org"
+	tests := []struct {
+		name              string
+		viciClientErr     error
+		keys              *vici.Message
+		shared            *vici.Message
+		connMsgs          []*vici.Message
+		metricName        string
+		wantMetricsHelp   string
+		wantMetricsLabels string
+		wantMetricsValue  int
+		wantState         string
+		wantMetricsCount  int
+	}{
+		{
+			name:             "connection error",
+			viciClientErr:    errors.New("some error"),
+			metricName:       "swtest_cred_private_keys",
+			wantMetricsHelp:  "Number of loaded private keys",
+			wantMetricsValue: 0,
+			wantMetricsCount: 2,
+		},
+		{
+			name:             "get-shared not supported",
+			keys:             credKeysMsg(serverKeyID),
+			metricName:       "swtest_cred_private_keys",
+			wantMetricsHelp:  "Number of loaded private keys",
+			wantMetricsValue: 0,
+			wantMetricsCount: 2,
+		},
+		{
+			name:             "private keys",
+			keys:             credKeysMsg(serverKeyID, "0123456789abcdef0123456789abcdef01234567"),
+			shared:           credKeysMsg(),
+			metricName:       "swtest_cred_private_keys",
+			wantMetricsHelp:  "Number of loaded private keys",
+			wantMetricsValue: 2,
+			wantMetricsCount: 2,
+		},
+		{
+			name:             "shared keys",
+			keys:             credKeysMsg(),
+			shared:           credKeysMsg("ike-psk", "eap-carol"),
+			metricName:       "swtest_cred_shared_keys",
+			wantMetricsHelp:  "Number of loaded shared secrets",
+			wantMetricsValue: 2,
+			wantMetricsCount: 2,
+		},
+		{
+			name:              "private key of the certificate loaded",
+			keys:              credKeysMsg(serverKeyID),
+			shared:            credKeysMsg(),
+			connMsgs:          []*vici.Message{localAuthConnMsg("home", "public key", serverSubject)},
+			metricName:        "swtest_conn_local_auth_credential_state",
+			wantMetricsHelp:   "State (loaded, missing, unknown) of the credential for the local authentication round of the connection",
+			wantMetricsLabels: `auth_class="public key",auth_round="1",conn_name="home"`,
+			wantState:         "loaded",
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "private key of the certificate missing",
+			keys:              credKeysMsg("0123456789abcdef0123456789abcdef01234567"),
+			shared:            credKeysMsg(),
+			connMsgs:          []*vici.Message{localAuthConnMsg("home", "public key", serverSubject)},
+			metricName:        "swtest_conn_local_auth_credential_state",
+			wantMetricsHelp:   "State (loaded, missing, unknown) of the credential for the local authentication round of the connection",
+			wantMetricsLabels: `auth_class="public key",auth_round="1",conn_name="home"`,
+			wantState:         "missing",
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "certificate not loaded",
+			keys:              credKeysMsg(serverKeyID),
+			shared:            credKeysMsg(),
+			connMsgs:          []*vici.Message{localAuthConnMsg("home", "public key", "C=CH, O=Cyber, CN=other.strongswan.org")},
+			metricName:        "swtest_conn_local_auth_credential_state",
+			wantMetricsHelp:   "State (loaded, missing, unknown) of the credential for the local authentication round of the connection",
+			wantMetricsLabels: `auth_class="public key",auth_round="1",conn_name="home"`,
+			wantState:         "unknown",
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "shared secret missing",
+			keys:              credKeysMsg(serverKeyID),
+			shared:            credKeysMsg(),
+			connMsgs:          []*vici.Message{localAuthConnMsg("psk", "pre-shared key")},
+			metricName:        "swtest_conn_local_auth_credential_state",
+			wantMetricsHelp:   "State (loaded, missing, unknown) of the credential for the local authentication round of the connection",
+			wantMetricsLabels: `auth_class="pre-shared key",auth_round="1",conn_name="psk"`,
+			wantState:         "missing",
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "shared secrets of unknown owners",
+			keys:              credKeysMsg(),
+			shared:            credKeysMsg("ike-psk"),
+			connMsgs:          []*vici.Message{localAuthConnMsg("psk", "pre-shared key")},
+			metricName:        "swtest_conn_local_auth_credential_state",
+			wantMetricsHelp:   "State (loaded, missing, unknown) of the credential for the local authentication round of the connection",
+			wantMetricsLabels: `auth_class="pre-shared key",auth_round="1",conn_name="psk"`,
+			wantState:         "unknown",
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "private key of the certificate with the identity loaded",
+			keys:              credKeysMsg(serverKeyID),
+			shared:            credKeysMsg(),
+			connMsgs:          []*vici.Message{localAuthConnMsg("home", "public key")},
+			metricName:        "swtest_conn_local_auth_credential_state",
+			wantMetricsHelp:   "State (loaded, missing, unknown) of the credential for the local authentication round of the connection",
+			wantMetricsLabels: `auth_class="public key",auth_round="1",conn_name="home"`,
+			wantState:         "loaded",
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "no certificate with the identity",
+			keys:              credKeysMsg(serverKeyID),
+			shared:            credKeysMsg(),
+			connMsgs:          []*vici.Message{connsMsg("home", withAuth(connMsg(), "local-1", authMsg("public key", "other.strongswan.org", nil, nil)))},
+			metricName:        "swtest_conn_local_auth_credential_state",
+			wantMetricsHelp:   "State (loaded, missing, unknown) of the credential for the local authentication round of the connection",
+			wantMetricsLabels: `auth_class="public key",auth_round="1",conn_name="home"`,
+			wantState:         "unknown",
+			wantMetricsCount:  5,
+		},
+		{
+			name:             "authentication without credential",
+			keys:             credKeysMsg(),
+			shared:           credKeysMsg(),
+			connMsgs:         []*vici.Message{localAuthConnMsg("any", "any")},
+			metricName:       "swtest_cred_private_keys",
+			wantMetricsHelp:  "Number of loaded private keys",
+			wantMetricsValue: 0,
+			wantMetricsCount: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmdMsgs := map[string]*vici.Message{}
+			if tt.keys != nil {
+				cmdMsgs["get-keys"] = tt.keys
+			}
+			if tt.shared != nil {
+				cmdMsgs["get-shared"] = tt.shared
+			}
+			c := NewCredsCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{
+					cmdMsgs:  cmdMsgs,
+					certMsgs: []*vici.Message{x509CertMsg("testdata/cert-ca.pem"), x509CertMsg("testdata/cert.pem")},
+					connMsgs: tt.connMsgs,
+				}, tt.viciClientErr
+			}, MetricsSchemaV1)
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
+
+			wantMetricsContent := fmt.Sprintf(`# HELP %s %s
+# TYPE %s gauge
+`, tt.metricName, tt.wantMetricsHelp, tt.metricName)
+			if tt.wantState == "" {
+				wantMetricsContent += fmt.Sprintf("%s{%s} %d\n", tt.metricName, tt.wantMetricsLabels, tt.wantMetricsValue)
+			} else {
+				// The StateSet has a series for every state.
+				for _, state := range credentialStates {
+					v := 0
+					if state == tt.wantState {
+						v = 1
+					}
+					wantMetricsContent += fmt.Sprintf("%s{%s,state=%q} %d\n", tt.metricName, tt.wantMetricsLabels, state, v)
+				}
+			}
+			if err := testutil.CollectAndCompare(c, strings.NewReader(wantMetricsContent), tt.metricName); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package strongswan
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+)
+
+// Reasons of crypto policy violations.
+const (
+	reasonIkeVersion        = "ike_version"
+	reasonEncryption        = "encryption_algorithm"
+	reasonEncryptionKeySize = "encryption_key_size"
+	reasonIntegrity         = "integrity_algorithm"
+	reasonPrf               = "prf_algorithm"
+	reasonDhGroup           = "dh_group"
+	reasonCertSignature     = "cert_signature_algorithm"
+	reasonCertKeySize       = "cert_key_size"
+)
+
+/*
+CryptoPolicy are the rules the negotiated IKE and CHILD SAs and the loaded certificates are evaluated against.
+Algorithms are named like strongSwan reports them in list-sas (e.g. AES_GCM_16, HMAC_SHA2_256_128,
+PRF_HMAC_SHA2_256, MODP_2048, CURVE_25519), certificate signature algorithms like Go names them (e.g.
+SHA256-RSA, ECDSA-SHA384). Rules without constraints permit everything.
+*/
+type CryptoPolicy struct {
+	Name string `json:"name"`
+	// IkeVersions are the permitted IKE versions.
+	IkeVersions          []int         `json:"ike_versions"`
+	Encryption           AlgorithmRule `json:"encryption"`
+	MinEncryptionKeySize int           `json:"min_encryption_key_size"`
+	Integrity            AlgorithmRule `json:"integrity"`
+	Prf                  AlgorithmRule `json:"prf"`
+	DhGroups             AlgorithmRule `json:"dh_groups"`
+	CertSignatures       AlgorithmRule `json:"cert_signature_algorithms"`
+	// MinCertKeySizes are the minimum key sizes of the certificates by key algorithm (RSA, ECDSA, Ed25519).
+	MinCertKeySizes map[string]int `json:"min_cert_key_sizes"`
+}
+
+// AlgorithmRule permits the algorithms matching one of the allowed patterns, or any if there is none, unless forbidden.
+type AlgorithmRule struct {
+	// Allowed and Forbidden are shell patterns like AES_GCM_* as matched by path.Match.
+	Allowed   []string `json:"allowed"`
+	Forbidden []string `json:"forbidden"`
+}
+
+type policyViolation struct {
+	reason string
+	value  string
+}
+
+// aesCiphers are the AES based encryption algorithms recommended by BSI and NIST for IKEv2 and ESP.
+var aesCiphers = []string{"AES_CBC", "AES_CTR", "AES_GCM_*", "AES_CCM_*"}
+
+// cryptoPolicyPresets are the built-in policies, selected by name instead of a policy file.
+var cryptoPolicyPresets = map[string]CryptoPolicy{
+	// BSI TR-02102-3, IKEv2 and IPsec with at least 3000 bit MODP groups and RSA keys.
+	"bsi-tr-02102": {
+		Name:                 "bsi-tr-02102",
+		IkeVersions:          []int{2},
+		Encryption:           AlgorithmRule{Allowed: aesCiphers},
+		MinEncryptionKeySize: 128,
+		Integrity:            AlgorithmRule{Allowed: []string{"HMAC_SHA2_*"}},
+		Prf:                  AlgorithmRule{Allowed: []string{"PRF_HMAC_SHA2_*"}},
+		DhGroups: AlgorithmRule{Allowed: []string{
+			"MODP_3072", "MODP_4096", "MODP_6144", "MODP_8192",
+			"ECP_256", "ECP_384", "ECP_521", "ECP_256_BP", "ECP_384_BP", "ECP_512_BP",
+		}},
+		CertSignatures:  AlgorithmRule{Allowed: []string{"SHA256-*", "SHA384-*", "SHA512-*", "ECDSA-SHA256", "ECDSA-SHA384", "ECDSA-SHA512"}},
+		MinCertKeySizes: map[string]int{"RSA": 3000, "ECDSA": 250},
+	},
+	// NIST SP 800-77 Rev. 1, IKEv2 and IPsec with at least 2048 bit MODP groups and RSA keys.
+	"nist-sp-800-77": {
+		Name:                 "nist-sp-800-77",
+		IkeVersions:          []int{2},
+		Encryption:           AlgorithmRule{Allowed: aesCiphers},
+		MinEncryptionKeySize: 128,
+		Integrity:            AlgorithmRule{Allowed: []string{"HMAC_SHA2_*", "AES_XCBC_96", "AES_CMAC_96"}},
+		Prf:                  AlgorithmRule{Allowed: []string{"PRF_HMAC_SHA2_*", "PRF_AES128_XCBC", "PRF_AES128_CMAC"}},
+		DhGroups: AlgorithmRule{Allowed: []string{
+			"MODP_2048", "MODP_3072", "MODP_4096", "MODP_6144", "MODP_8192",
+			"ECP_256", "ECP_384", "ECP_521", "CURVE_25519", "CURVE_448",
+		}},
+		CertSignatures:  AlgorithmRule{Allowed: []string{"SHA256-*", "SHA384-*", "SHA512-*", "ECDSA-SHA256", "ECDSA-SHA384", "ECDSA-SHA512", "Ed25519"}},
+		MinCertKeySizes: map[string]int{"RSA": 2048, "ECDSA": 256},
+	},
+}
+
+// LoadCryptoPolicy returns the built-in policy of the name, or reads the policy from the JSON file.
+func LoadCryptoPolicy(v string) (*CryptoPolicy, error) {
+	if preset, ok := cryptoPolicyPresets[v]; ok {
+		return &preset, nil
+	}
+	data, err := os.ReadFile(v)
+	if err != nil {
+		return nil, fmt.Errorf("unknown crypto policy: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var p CryptoPolicy
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid crypto policy '%v': %w", v, err)
+	}
+	for _, r := range []AlgorithmRule{p.Encryption, p.Integrity, p.Prf, p.DhGroups, p.CertSignatures} {
+		for _, pattern := range slices.Concat(r.Allowed, r.Forbidden) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid crypto policy '%v', pattern '%v': %w", v, pattern, err)
+			}
+		}
+	}
+	if p.Name == "" {
+		p.Name = path.Base(v)
+	}
+	return &p, nil
+}
+
+// permits reports if the algorithm is permitted, SAs without the algorithm (e.g. integrity with AEAD) are always permitted.
+func (r AlgorithmRule) permits(alg string) bool {
+	if alg == "" {
+		return true
+	}
+	matches := func(pattern string) bool {
+		ok, _ := path.Match(pattern, alg)
+		return ok
+	}
+	if slices.ContainsFunc(r.Forbidden, matches) {
+		return false
+	}
+	return len(r.Allowed) == 0 || slices.ContainsFunc(r.Allowed, matches)
+}
+
+func (p *CryptoPolicy) ikeViolations(sa IkeSa) []policyViolation {
+	var v []policyViolation
+	if len(p.IkeVersions) > 0 && !slices.Contains(p.IkeVersions, sa.Version) {
+		v = append(v, policyViolation{reasonIkeVersion, strconv.Itoa(sa.Version)})
+	}
+	return append(v, p.proposalViolations(sa.EncAlg, sa.EncKey, sa.IntegAlg, sa.PrfAlg, sa.DHGroup)...)
+}
+
+func (p *CryptoPolicy) childViolations(child ChildIkeSa) []policyViolation {
+	return p.proposalViolations(child.EncAlg, child.EncKey, child.IntegAlg, child.PrfAlg, child.DHGroup)
+}
+
+func (p *CryptoPolicy) proposalViolations(encAlg string, encKey int, integAlg string, prfAlg string, dhGroup string) []policyViolation {
+	var v []policyViolation
+	if !p.Encryption.permits(encAlg) {
+		v = append(v, policyViolation{reasonEncryption, encAlg})
+	}
+	if encKey > 0 && encKey < p.MinEncryptionKeySize {
+		v = append(v, policyViolation{reasonEncryptionKeySize, strconv.Itoa(encKey)})
+	}
+	if !p.Integrity.permits(integAlg) {
+		v = append(v, policyViolation{reasonIntegrity, integAlg})
+	}
+	if !p.Prf.permits(prfAlg) {
+		v = append(v, policyViolation{reasonPrf, prfAlg})
+	}
+	if !p.DhGroups.permits(dhGroup) {
+		v = append(v, policyViolation{reasonDhGroup, dhGroup})
+	}
+	return v
+}
+
+// certViolations evaluates the certificate, the signature of self-signed certificates is not relevant for their trust.
+func (p *CryptoPolicy) certViolations(cert *x509.Certificate) []policyViolation {
+	var v []policyViolation
+	if sigAlg := cert.SignatureAlgorithm.String(); !isSelfSigned(cert) && !p.CertSignatures.permits(sigAlg) {
+		v = append(v, policyViolation{reasonCertSignature, sigAlg})
+	}
+	if size := keySize(cert); size < p.MinCertKeySizes[cert.PublicKeyAlgorithm.String()] {
+		v = append(v, policyViolation{reasonCertKeySize, strconv.Itoa(size)})
+	}
+	return v
+}
@@ -0,0 +1,138 @@
+package strongswan
+
+import (
+	"crypto/x509"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+CryptoPolicyCollector evaluates the negotiated algorithms of every IKE and CHILD SA and the signature and key
+algorithms of the loaded X509 certificates against the crypto policy. Every violated rule is exported with
+its reason and the offending value, e.g. reason="dh_group" and value="MODP_1024".
+*/
+type CryptoPolicyCollector struct {
+	viciClientFn viciClientFn
+	cache        *SasCache
+	policy       *CryptoPolicy
+
+	policyInfo          *prometheus.Desc
+	ikeCompliant        *prometheus.Desc
+	ikeViolation        *prometheus.Desc
+	childCompliant      *prometheus.Desc
+	childViolation      *prometheus.Desc
+	certCompliant       *prometheus.Desc
+	certPolicyViolation *prometheus.Desc
+}
+
+func NewCryptoPolicyCollector(prefix string, viciClientFn viciClientFn, cache *SasCache, policy *CryptoPolicy) prometheus.Collector {
+	ikeLabels := []string{"ike_name", "ike_id"}
+	childLabels := []string{"ike_name", "ike_id", "child_name", "child_id"}
+	certLabels := []string{"serial_number", "subject", "issuer"}
+	return &CryptoPolicyCollector{
+		viciClientFn: viciClientFn,
+		cache:        cache,
+		policy:       policy,
+
+		policyInfo: prometheus.NewDesc(
+			prefix+"crypto_policy_info",
+			"Crypto policy the SAs and certificates are evaluated against",
+			[]string{"name"}, nil,
+		),
+		ikeCompliant: prometheus.NewDesc(
+			prefix+"ike_policy_compliant",
+			"Flag if the IKE version and negotiated algorithms of the IKE SA comply with the crypto policy",
+			ikeLabels, nil,
+		),
+		ikeViolation: prometheus.NewDesc(
+			prefix+"ike_policy_violation",
+			"Crypto policy violation of the IKE SA with the reason and the offending value",
+			append(ikeLabels, "reason", "value"), nil,
+		),
+		childCompliant: prometheus.NewDesc(
+			prefix+"child_policy_compliant",
+			"Flag if the negotiated algorithms of the CHILD SA comply with the crypto policy",
+			childLabels, nil,
+		),
+		childViolation: prometheus.NewDesc(
+			prefix+"child_policy_violation",
+			"Crypto policy violation of the CHILD SA with the reason and the offending value",
+			append(childLabels, "reason", "value"), nil,
+		),
+		certCompliant: prometheus.NewDesc(
+			prefix+"cert_policy_compliant",
+			"Flag if the signature and key algorithms of the X509 certificate comply with the crypto policy",
+			certLabels, nil,
+		),
+		certPolicyViolation: prometheus.NewDesc(
+			prefix+"cert_policy_violation",
+			"Crypto policy violation of the X509 certificate with the reason and the offending value",
+			append(certLabels, "reason", "value"), nil,
+		),
+	}
+}
+
+func (c *CryptoPolicyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.policyInfo
+	ch <- c.ikeCompliant
+	ch <- c.ikeViolation
+	ch <- c.childCompliant
+	ch <- c.childViolation
+	ch <- c.certCompliant
+	ch <- c.certPolicyViolation
+}
+
+func (c *CryptoPolicyCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, newScrape(c.viciClientFn, c.cache))
+}
+
+func (c *CryptoPolicyCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	ch <- prometheus.MustNewConstMetric(
+		c.policyInfo,
+		prometheus.GaugeValue,
+		1,
+		c.policy.Name,
+	)
+
+	sas, err := s.listSas()
+	if err == nil {
+		for _, sa := range sas {
+			c.collectIkeMetrics(sa, ch)
+		}
+	}
+
+	certs, err := s.listX509Certs()
+	if err == nil {
+		for _, cert := range certs {
+			c.collectCertMetrics(cert, ch)
+		}
+	}
+}
+
+func (c *CryptoPolicyCollector) collectIkeMetrics(sa IkeSa, ch chan<- prometheus.Metric) {
+	c.collectViolations(ch, c.ikeCompliant, c.ikeViolation, c.policy.ikeViolations(sa), sa.Name, sa.UniqueID)
+	for _, child := range sa.Children {
+		c.collectViolations(ch, c.childCompliant, c.childViolation, c.policy.childViolations(child), sa.Name, sa.UniqueID, child.Name, child.UniqueID)
+	}
+}
+
+func (c *CryptoPolicyCollector) collectCertMetrics(cert *x509.Certificate, ch chan<- prometheus.Metric) {
+	c.collectViolations(ch, c.certCompliant, c.certPolicyViolation, c.policy.certViolations(cert), formatSerialNumber(cert.SerialNumber), cert.Subject.String(), cert.Issuer.String())
+}
+
+func (c *CryptoPolicyCollector) collectViolations(ch chan<- prometheus.Metric, compliant *prometheus.Desc, violation *prometheus.Desc, violations []policyViolation, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(
+		compliant,
+		prometheus.GaugeValue,
+		boolToFloat(len(violations) == 0),
+		labels...,
+	)
+	for _, v := range violations {
+		ch <- prometheus.MustNewConstMetric(
+			violation,
+			prometheus.GaugeValue,
+			1,
+			append(labels, v.reason, v.value)...,
+		)
+	}
+}
@@ -0,0 +1,238 @@
+package strongswan
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func proposalMsg(m *vici.Message, encAlg string, encKey int, integAlg string, prfAlg string, dhGroup string) *vici.Message {
+	m.Set("encr-alg", encAlg)
+	if encKey > 0 {
+		m.Set("encr-keysize", encKey)
+	}
+	if integAlg != "" {
+		m.Set("integ-alg", integAlg)
+	}
+	if prfAlg != "" {
+		m.Set("prf-alg", prfAlg)
+	}
+	if dhGroup != "" {
+		m.Set("dh-group", dhGroup)
+	}
+	return m
+}
+
+func TestLoadCryptoPolicy(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, data string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(data), 0o600))
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:     "preset",
+			policy:   "bsi-tr-02102",
+			wantName: "bsi-tr-02102",
+		},
+		{
+			name:     "policy file",
+			policy:   "testdata/crypto-policy.json",
+			wantName: "corporate",
+		},
+		{
+			name:     "policy file without name",
+			policy:   writeFile("site.json", `{"ike_versions": [2]}`),
+			wantName: "site.json",
+		},
+		{
+			name:    "unknown policy",
+			policy:  "fips",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			policy:  writeFile("unknown.json", `{"ike_version": [2]}`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid pattern",
+			policy:  writeFile("pattern.json", `{"encryption": {"allowed": ["AES_[GCM"]}}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := LoadCryptoPolicy(tt.policy)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantName, p.Name)
+		})
+	}
+}
+
+func TestCryptoPolicyCollector_Metrics(t *testing.T) {
+	legacy := ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{
+		"net-1": proposalMsg(childSaMsg("net", "1", "INSTALLED"), "3DES_CBC", 0, "HMAC_SHA1_96", "", "MODP_1024"),
+	})
+	legacy.Set("version", 1)
+	proposalMsg(legacy, "3DES_CBC", 0, "HMAC_SHA1_96", "PRF_HMAC_SHA1", "MODP_1024")
+	modern := ikeSaMsg("2", "ESTABLISHED", map[string]*vici.Message{
+		"net-2": proposalMsg(childSaMsg("net", "2", "INSTALLED"), "AES_GCM_16", 128, "", "", ""),
+	})
+	modern.Set("version", 2)
+	proposalMsg(modern, "AES_GCM_16", 256, "", "PRF_HMAC_SHA2_256", "CURVE_25519")
+	sas := vici.NewMessage()
+	sas.Set("legacy", legacy)
+	sas.Set("modern", modern)
+
+	certs := []*vici.Message{x509CertMsg("testdata/cert.pem"), x509CertMsg("testdata/chain-leaf.pem")}
+
+	tests := []struct {
+		name          string
+		policy        string
+		viciClientErr error
+		metricNames   []string
+		want          string
+		wantCount     int
+	}{
+		{
+			name:          "connection error",
+			policy:        "nist-sp-800-77",
+			viciClientErr: errors.New("some error"),
+			want: `# HELP swtest_crypto_policy_info Crypto policy the SAs and certificates are evaluated against
+# TYPE swtest_crypto_policy_info gauge
+swtest_crypto_policy_info{name="nist-sp-800-77"} 1
+`,
+			wantCount: 1,
+		},
+		{
+			name:   "IKE SAs against preset",
+			policy: "nist-sp-800-77",
+			metricNames: []string{
+				"swtest_ike_policy_compliant", "swtest_ike_policy_violation",
+				"swtest_child_policy_compliant", "swtest_child_policy_violation",
+			},
+			want: `# HELP swtest_child_policy_compliant Flag if the negotiated algorithms of the CHILD SA comply with the crypto policy
+# TYPE swtest_child_policy_compliant gauge
+swtest_child_policy_compliant{child_id="1",child_name="net",ike_id="1",ike_name="legacy"} 0
+swtest_child_policy_compliant{child_id="2",child_name="net",ike_id="2",ike_name="modern"} 1
+# HELP swtest_child_policy_violation Crypto policy violation of the CHILD SA with the reason and the offending value
+# TYPE swtest_child_policy_violation gauge
+swtest_child_policy_violation{child_id="1",child_name="net",ike_id="1",ike_name="legacy",reason="dh_group",value="MODP_1024"} 1
+swtest_child_policy_violation{child_id="1",child_name="net",ike_id="1",ike_name="legacy",reason="encryption_algorithm",value="3DES_CBC"} 1
+swtest_child_policy_violation{child_id="1",child_name="net",ike_id="1",ike_name="legacy",reason="integrity_algorithm",value="HMAC_SHA1_96"} 1
+# HELP swtest_ike_policy_compliant Flag if the IKE version and negotiated algorithms of the IKE SA comply with the crypto policy
+# TYPE swtest_ike_policy_compliant gauge
+swtest_ike_policy_compliant{ike_id="1",ike_name="legacy"} 0
+swtest_ike_policy_compliant{ike_id="2",ike_name="modern"} 1
+# HELP swtest_ike_policy_violation Crypto policy violation of the IKE SA with the reason and the offending value
+# TYPE swtest_ike_policy_violation gauge
+swtest_ike_policy_violation{ike_id="1",ike_name="legacy",reason="dh_group",value="MODP_1024"} 1
+swtest_ike_policy_violation{ike_id="1",ike_name="legacy",reason="encryption_algorithm",value="3DES_CBC"} 1
+swtest_ike_policy_violation{ike_id="1",ike_name="legacy",reason="ike_version",value="1"} 1
+swtest_ike_policy_violation{ike_id="1",ike_name="legacy",reason="integrity_algorithm",value="HMAC_SHA1_96"} 1
+swtest_ike_policy_violation{ike_id="1",ike_name="legacy",reason="prf_algorithm",value="PRF_HMAC_SHA1"} 1
+`,
+			wantCount: 15,
+		},
+		{
+			name:   "IKE SAs against policy file",
+			policy: "testdata/crypto-policy.json",
+			metricNames: []string{
+				"swtest_crypto_policy_info",
+				"swtest_ike_policy_violation", "swtest_child_policy_violation",
+			},
+			want: `# HELP swtest_child_policy_violation Crypto policy violation of the CHILD SA with the reason and the offending value
+# TYPE swtest_child_policy_violation gauge
+swtest_child_policy_violation{child_id="1",child_name="net",ike_id="1",ike_name="legacy",reason="dh_group",value="MODP_1024"} 1
+swtest_child_policy_violation{child_id="1",child_name="net",ike_id="1",ike_name="legacy",reason="encryption_algorithm",value="3DES_CBC"} 1
+swtest_child_policy_violation{child_id="2",child_name="net",ike_id="2",ike_name="modern",reason="encryption_key_size",value="128"} 1
+# HELP swtest_crypto_policy_info Crypto policy the SAs and certificates are evaluated against
+# TYPE swtest_crypto_policy_info gauge
+swtest_crypto_policy_info{name="corporate"} 1
+# HELP swtest_ike_policy_violation Crypto policy violation of the IKE SA with the reason and the offending value
+# TYPE swtest_ike_policy_violation gauge
+swtest_ike_policy_violation{ike_id="1",ike_name="legacy",reason="dh_group",value="MODP_1024"} 1
+swtest_ike_policy_violation{ike_id="1",ike_name="legacy",reason="encryption_algorithm",value="3DES_CBC"} 1
+swtest_ike_policy_violation{ike_id="1",ike_name="legacy",reason="ike_version",value="1"} 1
+`,
+			wantCount: 15,
+		},
+		{
+			name:        "certificates against preset",
+			policy:      "nist-sp-800-77",
+			metricNames: []string{"swtest_cert_policy_compliant", "swtest_cert_policy_violation"},
+			want: `# HELP swtest_cert_policy_compliant Flag if the signature and key algorithms of the X509 certificate comply with the crypto policy
+# TYPE swtest_cert_policy_compliant gauge
+swtest_cert_policy_compliant{issuer="CN=Chain Intermediate CA,O=Chain,C=CH",serial_number="03:00",subject="CN=gw.chain.example,O=Chain,C=CH"} 1
+swtest_cert_policy_compliant{issuer="CN=Cyber Root CA,O=Cyber,C=CH",serial_number="76:38:40:b8:25:18:44:0a",subject="CN=server.strongswan.org,O=Cyber,C=CH"} 1
+`,
+			wantCount: 15,
+		},
+		{
+			name:        "certificates against policy file",
+			policy:      "testdata/crypto-policy.json",
+			metricNames: []string{"swtest_cert_policy_compliant", "swtest_cert_policy_violation"},
+			want: `# HELP swtest_cert_policy_compliant Flag if the signature and key algorithms of the X509 certificate comply with the crypto policy
+# TYPE swtest_cert_policy_compliant gauge
+swtest_cert_policy_compliant{issuer="CN=Chain Intermediate CA,O=Chain,C=CH",serial_number="03:00",subject="CN=gw.chain.example,O=Chain,C=CH"} 0
+swtest_cert_policy_compliant{issuer="CN=Cyber Root CA,O=Cyber,C=CH",serial_number="76:38:40:b8:25:18:44:0a",subject="CN=server.strongswan.org,O=Cyber,C=CH"} 1
+# HELP swtest_cert_policy_violation Crypto policy violation of the X509 certificate with the reason and the offending value
+# TYPE swtest_cert_policy_violation gauge
+swtest_cert_policy_violation{issuer="CN=Chain Intermediate CA,O=Chain,C=CH",reason="cert_key_size",serial_number="03:00",subject="CN=gw.chain.example,O=Chain,C=CH",value="256"} 1
+swtest_cert_policy_violation{issuer="CN=Chain Intermediate CA,O=Chain,C=CH",reason="cert_signature_algorithm",serial_number="03:00",subject="CN=gw.chain.example,O=Chain,C=CH",value="ECDSA-SHA256"} 1
+`,
+			wantCount: 15,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := LoadCryptoPolicy(tt.policy)
+			require.NoError(t, err)
+			c := NewCryptoPolicyCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{saMsgs: []*vici.Message{sas}, certMsgs: certs}, tt.viciClientErr
+			}, nil, policy)
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantCount, cnt, "metrics count")
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want), tt.metricNames...); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
+
+func TestCryptoPolicyCollector_CertsOfDifferentIssuers(t *testing.T) {
+	policy, err := LoadCryptoPolicy("nist-sp-800-77")
+	require.NoError(t, err)
+	c := NewCryptoPolicyCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{certMsgs: []*vici.Message{x509CertMsg("testdata/cert-issuer-a.pem"), x509CertMsg("testdata/cert-issuer-b.pem")}}, nil
+	}, nil, policy)
+
+	// Both certificates have the same serial number and subject.
+	want := `# HELP swtest_cert_policy_compliant Flag if the signature and key algorithms of the X509 certificate comply with the crypto policy
+# TYPE swtest_cert_policy_compliant gauge
+swtest_cert_policy_compliant{issuer="CN=Cyber CA A,O=Cyber,C=CH",serial_number="05",subject="CN=gw.cyber.example,O=Cyber,C=CH"} 1
+swtest_cert_policy_compliant{issuer="CN=Cyber CA B,O=Cyber,C=CH",serial_number="05",subject="CN=gw.cyber.example,O=Cyber,C=CH"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "swtest_cert_policy_compliant"); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
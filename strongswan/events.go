@@ -0,0 +1,167 @@
+package strongswan
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+const eventBufferSize = 1024
+
+type ViciEventClient interface {
+	Subscribe(events ...string) error
+	NotifyEvents(c chan<- vici.Event)
+	Close() error
+}
+
+type viciEventClientFn func() (ViciEventClient, error)
+
+type eventHandler interface {
+	handleEvent(e vici.Event)
+	// resync is called whenever the event stream is (re-)established, events may have been missed before.
+	resync()
+}
+
+/*
+EventListener keeps a dedicated Vici session subscribed to the events required by the registered
+handlers and dispatches the received events to them. The session is re-established with exponential
+backoff when charon closes it.
+
+govici silently drops events while the event buffer is full. Every time the listener finds the buffer
+full it counts an overflow and resyncs the handlers, as events may have been missed.
+*/
+type EventListener struct {
+	clientFn   viciEventClientFn
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	events   []string
+	handlers map[string][]eventHandler
+	all      []eventHandler
+
+	overflows   atomic.Int64
+	overflowCnt *prometheus.Desc
+}
+
+func NewEventListener(prefix string, clientFn viciEventClientFn, minBackoff time.Duration, maxBackoff time.Duration) *EventListener {
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	return &EventListener{
+		clientFn:   clientFn,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		handlers:   make(map[string][]eventHandler),
+
+		overflowCnt: prometheus.NewDesc(
+			prefix+"vici_event_buffer_overflows_total",
+			"Number of times the Vici event buffer was full, events received meanwhile were dropped",
+			nil, nil,
+		),
+	}
+}
+
+func (l *EventListener) Describe(ch chan<- *prometheus.Desc) {
+	ch <- l.overflowCnt
+}
+
+func (l *EventListener) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(
+		l.overflowCnt,
+		prometheus.CounterValue,
+		float64(l.overflows.Load()),
+	)
+}
+
+// enabled reports if any handler is registered.
+func (l *EventListener) enabled() bool {
+	return len(l.events) > 0
+}
+
+// register adds the handler for the given events. It must be called before Run.
+func (l *EventListener) register(h eventHandler, events ...string) {
+	for _, e := range events {
+		if !slices.Contains(l.events, e) {
+			l.events = append(l.events, e)
+		}
+		l.handlers[e] = append(l.handlers[e], h)
+	}
+	if !slices.Contains(l.all, h) {
+		l.all = append(l.all, h)
+	}
+}
+
+// Run receives events until the context is canceled.
+func (l *EventListener) Run(ctx context.Context) {
+	if !l.enabled() {
+		return
+	}
+
+	failures := 0
+	for {
+		if err := l.listen(ctx); err != nil {
+			failures++
+			log.Logger.Warnf("Vici event stream failed: %v", err)
+		} else {
+			failures = 0
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		t := time.NewTimer(expBackoff(l.minBackoff, l.maxBackoff, max(failures, 1)))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (l *EventListener) listen(ctx context.Context) error {
+	s, err := l.clientFn()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err := s.Subscribe(l.events...); err != nil {
+		return err
+	}
+	ch := make(chan vici.Event, eventBufferSize)
+	s.NotifyEvents(ch)
+	log.Logger.Debugf("Subscribed to Vici events: %v", l.events)
+
+	for _, h := range l.all {
+		h.resync()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-ch:
+			if !ok {
+				log.Logger.Info("Vici event stream closed.")
+				return nil
+			}
+			for _, h := range l.handlers[e.Name] {
+				h.handleEvent(e)
+			}
+			// The buffer was full before this event was received, so govici may have dropped events.
+			if len(ch) == cap(ch)-1 {
+				l.overflows.Add(1)
+				log.Logger.Warn("Vici event buffer overflow, events may have been dropped.")
+				for _, h := range l.all {
+					h.resync()
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package strongswan
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+const (
+	directionUp   = "up"
+	directionDown = "down"
+)
+
+var eventsCollectorEvents = []string{eventIkeUpdown, eventIkeRekey, eventChildUpdown, eventChildRekey}
+
+type ikeCounterKey struct {
+	ikeName   string
+	direction string
+}
+
+type childCounterKey struct {
+	ikeName   string
+	childName string
+	direction string
+}
+
+type EventsCollector struct {
+	mu          sync.Mutex
+	ikeUpdown   map[ikeCounterKey]int
+	ikeRekey    map[string]int
+	childUpdown map[childCounterKey]int
+	childRekey  map[childCounterKey]int
+
+	ikeUpdownCnt   *prometheus.Desc
+	ikeRekeyCnt    *prometheus.Desc
+	childUpdownCnt *prometheus.Desc
+	childRekeyCnt  *prometheus.Desc
+}
+
+func NewEventsCollector(prefix string) *EventsCollector {
+	return &EventsCollector{
+		ikeUpdown:   make(map[ikeCounterKey]int),
+		ikeRekey:    make(map[string]int),
+		childUpdown: make(map[childCounterKey]int),
+		childRekey:  make(map[childCounterKey]int),
+
+		ikeUpdownCnt: prometheus.NewDesc(
+			prefix+"ike_updown_total",
+			"Number of IKE SAs which went up or down",
+			[]string{"ike_name", "direction"}, nil,
+		),
+		ikeRekeyCnt: prometheus.NewDesc(
+			prefix+"ike_rekey_total",
+			"Number of IKE SA rekeys",
+			[]string{"ike_name"}, nil,
+		),
+		childUpdownCnt: prometheus.NewDesc(
+			prefix+"child_updown_total",
+			"Number of CHILD SAs which went up or down",
+			[]string{"ike_name", "child_name", "direction"}, nil,
+		),
+		childRekeyCnt: prometheus.NewDesc(
+			prefix+"child_rekey_total",
+			"Number of CHILD SA rekeys",
+			[]string{"ike_name", "child_name"}, nil,
+		),
+	}
+}
+
+func (c *EventsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ikeUpdownCnt
+	ch <- c.ikeRekeyCnt
+	ch <- c.childUpdownCnt
+	ch <- c.childRekeyCnt
+}
+
+func (c *EventsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, v := range c.ikeUpdown {
+		ch <- prometheus.MustNewConstMetric(
+			c.ikeUpdownCnt,
+			prometheus.CounterValue,
+			float64(v),
+			k.ikeName, k.direction,
+		)
+	}
+	for ikeName, v := range c.ikeRekey {
+		ch <- prometheus.MustNewConstMetric(
+			c.ikeRekeyCnt,
+			prometheus.CounterValue,
+			float64(v),
+			ikeName,
+		)
+	}
+	for k, v := range c.childUpdown {
+		ch <- prometheus.MustNewConstMetric(
+			c.childUpdownCnt,
+			prometheus.CounterValue,
+			float64(v),
+			k.ikeName, k.childName, k.direction,
+		)
+	}
+	for k, v := range c.childRekey {
+		ch <- prometheus.MustNewConstMetric(
+			c.childRekeyCnt,
+			prometheus.CounterValue,
+			float64(v),
+			k.ikeName, k.childName,
+		)
+	}
+}
+
+func (c *EventsCollector) handleEvent(e vici.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	direction := directionDown
+	if e.Message.Get("up") == "yes" {
+		direction = directionUp
+	}
+
+	switch e.Name {
+	case eventIkeUpdown:
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			c.ikeUpdown[ikeCounterKey{ikeName: ikeSa.Name, direction: direction}]++
+		}
+	case eventIkeRekey:
+		for _, name := range e.Message.Keys() {
+			if _, ok := e.Message.Get(name).(*vici.Message); ok {
+				c.ikeRekey[name]++
+			}
+		}
+	case eventChildUpdown:
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			for _, child := range ikeSa.Children {
+				c.childUpdown[childCounterKey{ikeName: ikeSa.Name, childName: child.Name, direction: direction}]++
+			}
+		}
+	case eventChildRekey:
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			childSas, ok := e.Message.Get(ikeSa.Name).(*vici.Message).Get("child-sas").(*vici.Message)
+			if !ok {
+				continue
+			}
+			for _, name := range childSas.Keys() {
+				_, newSa, err := unmarshalRekey[ChildIkeSa](childSas, name)
+				if err != nil {
+					log.Logger.Warnf("Message unmarshal error: %v", err)
+					continue
+				}
+				c.childRekey[childCounterKey{ikeName: ikeSa.Name, childName: newSa.Name}]++
+			}
+		}
+	}
+}
+
+// resync is a no-op, events missed while the event stream was down are not counted.
+func (c *EventsCollector) resync() {}
@@ -0,0 +1,46 @@
+package strongswan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func TestEventsCollector_Metrics(t *testing.T) {
+	c := NewEventsCollector("swtest_")
+	require.Equal(t, 0, testutil.CollectAndCount(c), "metrics count")
+
+	c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(true, "ike-a", ikeSaMsg("1", "ESTABLISHED", nil))})
+	c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(false, "ike-a", ikeSaMsg("1", "DELETING", nil))})
+	c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(true, "ike-a", ikeSaMsg("2", "ESTABLISHED", nil))})
+
+	ikeRekey := vici.NewMessage()
+	ikeRekey.Set("ike-a", rekeyMsg(ikeSaMsg("2", "REKEYED", nil), ikeSaMsg("3", "ESTABLISHED", nil)))
+	c.handleEvent(vici.Event{Name: eventIkeRekey, Message: ikeRekey})
+
+	c.handleEvent(vici.Event{Name: eventChildUpdown, Message: eventMsg(true, "ike-a", ikeSaMsg("3", "ESTABLISHED", map[string]*vici.Message{"net-1": childSaMsg("net", "1", "INSTALLED")}))})
+	childRekey := ikeSaMsg("3", "ESTABLISHED", map[string]*vici.Message{"net": rekeyMsg(childSaMsg("net", "1", "REKEYED"), childSaMsg("net", "2", "INSTALLED"))})
+	c.handleEvent(vici.Event{Name: eventChildRekey, Message: eventMsg(false, "ike-a", childRekey)})
+	c.handleEvent(vici.Event{Name: eventChildRekey, Message: eventMsg(false, "ike-a", childRekey)})
+
+	want := `# HELP swtest_child_rekey_total Number of CHILD SA rekeys
+# TYPE swtest_child_rekey_total counter
+swtest_child_rekey_total{child_name="net",ike_name="ike-a"} 2
+# HELP swtest_child_updown_total Number of CHILD SAs which went up or down
+# TYPE swtest_child_updown_total counter
+swtest_child_updown_total{child_name="net",direction="up",ike_name="ike-a"} 1
+# HELP swtest_ike_rekey_total Number of IKE SA rekeys
+# TYPE swtest_ike_rekey_total counter
+swtest_ike_rekey_total{ike_name="ike-a"} 1
+# HELP swtest_ike_updown_total Number of IKE SAs which went up or down
+# TYPE swtest_ike_updown_total counter
+swtest_ike_updown_total{direction="down",ike_name="ike-a"} 1
+swtest_ike_updown_total{direction="up",ike_name="ike-a"} 2
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
@@ -0,0 +1,157 @@
+package strongswan
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+type fakeViciEventClient struct {
+	subscribed []string
+	ch         chan<- vici.Event
+	ready      chan struct{}
+}
+
+func (fec *fakeViciEventClient) Subscribe(events ...string) error {
+	fec.subscribed = events
+	return nil
+}
+
+func (fec *fakeViciEventClient) NotifyEvents(c chan<- vici.Event) {
+	fec.ch = c
+	close(fec.ready)
+}
+
+func (fec *fakeViciEventClient) Close() error {
+	return nil
+}
+
+type fakeEventHandler struct {
+	mu      sync.Mutex
+	events  []string
+	resyncs int
+}
+
+func (feh *fakeEventHandler) handleEvent(e vici.Event) {
+	feh.mu.Lock()
+	defer feh.mu.Unlock()
+	feh.events = append(feh.events, e.Name)
+}
+
+func (feh *fakeEventHandler) resync() {
+	feh.mu.Lock()
+	defer feh.mu.Unlock()
+	feh.resyncs++
+}
+
+func (feh *fakeEventHandler) state() ([]string, int) {
+	feh.mu.Lock()
+	defer feh.mu.Unlock()
+	return append([]string(nil), feh.events...), feh.resyncs
+}
+
+func TestEventListener_Run(t *testing.T) {
+	clients := make(chan *fakeViciEventClient, 3)
+	dialCalls := 0
+	l := NewEventListener("swtest_", func() (ViciEventClient, error) {
+		dialCalls++
+		if dialCalls == 2 {
+			return nil, errors.New("connection refused")
+		}
+		fec := &fakeViciEventClient{ready: make(chan struct{})}
+		clients <- fec
+		return fec, nil
+	}, time.Millisecond, time.Millisecond)
+
+	h1 := &fakeEventHandler{}
+	h2 := &fakeEventHandler{}
+	l.register(h1, eventIkeUpdown, eventChildUpdown)
+	l.register(h2, eventChildUpdown)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		l.Run(ctx)
+		close(done)
+	}()
+
+	fec := <-clients
+	<-fec.ready
+	require.Equal(t, []string{eventIkeUpdown, eventChildUpdown}, fec.subscribed)
+	fec.ch <- vici.Event{Name: eventIkeUpdown, Message: vici.NewMessage()}
+	fec.ch <- vici.Event{Name: eventChildUpdown, Message: vici.NewMessage()}
+	close(fec.ch)
+
+	// The stream is re-established after the failed attempt and the handlers are resynced.
+	fec = <-clients
+	<-fec.ready
+	fec.ch <- vici.Event{Name: eventChildUpdown, Message: vici.NewMessage()}
+	require.Eventually(t, func() bool {
+		events, resyncs := h2.state()
+		return len(events) == 2 && resyncs == 2
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	events, resyncs := h1.state()
+	require.Equal(t, []string{eventIkeUpdown, eventChildUpdown, eventChildUpdown}, events)
+	require.Equal(t, 2, resyncs)
+	require.Equal(t, 3, dialCalls, "number of dial calls")
+}
+
+// blockingEventHandler blocks on the first event until released.
+type blockingEventHandler struct {
+	fakeEventHandler
+	received chan struct{}
+	release  chan struct{}
+}
+
+func (beh *blockingEventHandler) handleEvent(e vici.Event) {
+	if events, _ := beh.state(); len(events) == 0 {
+		close(beh.received)
+		<-beh.release
+	}
+	beh.fakeEventHandler.handleEvent(e)
+}
+
+func TestEventListener_Overflow(t *testing.T) {
+	fec := &fakeViciEventClient{ready: make(chan struct{})}
+	l := NewEventListener("swtest_", func() (ViciEventClient, error) {
+		return fec, nil
+	}, time.Minute, time.Minute)
+	h := &blockingEventHandler{received: make(chan struct{}), release: make(chan struct{})}
+	l.register(h, eventIkeUpdown)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+
+	<-fec.ready
+	fec.ch <- vici.Event{Name: eventIkeUpdown, Message: vici.NewMessage()}
+	<-h.received
+	// The buffer fills up while the handler is busy.
+	for range eventBufferSize {
+		fec.ch <- vici.Event{Name: eventIkeUpdown, Message: vici.NewMessage()}
+	}
+	close(h.release)
+
+	require.Eventually(t, func() bool {
+		events, _ := h.state()
+		return len(events) == eventBufferSize+1
+	}, time.Second, time.Millisecond)
+	_, resyncs := h.state()
+	require.Equal(t, 2, resyncs, "resync after subscribing and after the overflow")
+	if err := testutil.CollectAndCompare(l, strings.NewReader(`# HELP swtest_vici_event_buffer_overflows_total Number of times the Vici event buffer was full, events received meanwhile were dropped
+# TYPE swtest_vici_event_buffer_overflows_total counter
+swtest_vici_event_buffer_overflows_total 1
+`)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
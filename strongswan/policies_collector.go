@@ -0,0 +1,122 @@
+package strongswan
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+// policyTypes are the kinds of installed policies, in the order of the list-policies flags.
+var policyTypes = []string{"trap", "drop", "pass"}
+
+/*
+PoliciesCollector exposes the trap and shunt (drop, pass) policies installed via Vici. Trap policies
+trigger the negotiation of a CHILD_SA on matching traffic, so a missing trap policy means the tunnel is
+never established.
+*/
+type PoliciesCollector struct {
+	viciClientFn viciClientFn
+
+	policyCnt  *prometheus.Desc
+	policyInfo *prometheus.Desc
+}
+
+func NewPoliciesCollector(prefix string, viciClientFn viciClientFn) prometheus.Collector {
+	return &PoliciesCollector{
+		viciClientFn: viciClientFn,
+
+		policyCnt: prometheus.NewDesc(
+			prefix+"policy_count",
+			"Number of installed policies of the type (trap, drop, pass)",
+			[]string{"type"}, nil,
+		),
+		policyInfo: prometheus.NewDesc(
+			prefix+"policy_info",
+			"Installed trap or shunt policy with its mode and traffic selectors",
+			[]string{"policy_name", "ike_name", "child_name", "type", "mode", "local_ts", "remote_ts"}, nil,
+		),
+	}
+}
+
+func (c *PoliciesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.policyCnt
+	ch <- c.policyInfo
+}
+
+func (c *PoliciesCollector) Collect(ch chan<- prometheus.Metric) {
+	policies, err := c.listPolicies()
+	cnt := make(map[string]int, len(policyTypes))
+	if err == nil {
+		for _, p := range policies {
+			pt := policyType(p.Mode)
+			cnt[pt]++
+			ch <- prometheus.MustNewConstMetric(
+				c.policyInfo,
+				prometheus.GaugeValue,
+				1,
+				p.Name, p.Ike, p.Child, pt, strings.ToLower(p.Mode), strings.Join(p.LocalTS, ";"), strings.Join(p.RemoteTS, ";"),
+			)
+		}
+	}
+	for _, pt := range policyTypes {
+		ch <- prometheus.MustNewConstMetric(
+			c.policyCnt,
+			prometheus.GaugeValue,
+			float64(cnt[pt]),
+			pt,
+		)
+	}
+}
+
+// policyType derives the kind of policy from its mode, trap policies have the IPsec mode of the CHILD_SA.
+func policyType(mode string) string {
+	switch m := strings.ToLower(mode); m {
+	case "drop", "pass":
+		return m
+	default:
+		return "trap"
+	}
+}
+
+func (c *PoliciesCollector) listPolicies() ([]Policy, error) {
+	s, err := c.viciClientFn()
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	req := vici.NewMessage()
+	for _, pt := range policyTypes {
+		if err := req.Set(pt, "yes"); err != nil {
+			return nil, err
+		}
+	}
+	msgs, err := s.StreamedCommandRequest("list-policies", "list-policy", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []Policy
+	for _, m := range msgs {
+		if err = m.Err(); err != nil {
+			log.Logger.Warnf("Message error: %v", err)
+			return nil, err
+		}
+		for _, name := range m.Keys() {
+			policyMsg, ok := m.Get(name).(*vici.Message)
+			if !ok {
+				continue
+			}
+			var policy Policy
+			if e := vici.UnmarshalMessage(policyMsg, &policy); e != nil {
+				log.Logger.Warnf("Message unmarshal error: %v", e)
+				continue
+			}
+			policy.Name = name
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
@@ -0,0 +1,113 @@
+package strongswan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func policyMsg(name string, ike string, child string, mode string) *vici.Message {
+	p := vici.NewMessage()
+	if ike != "" {
+		p.Set("ike", ike)
+	}
+	p.Set("child", child)
+	p.Set("mode", mode)
+	p.Set("local-ts", []string{"10.1.0.0/16"})
+	p.Set("remote-ts", []string{"10.2.0.0/16", "10.3.0.0/16"})
+	m := vici.NewMessage()
+	m.Set(name, p)
+	return m
+}
+
+func TestPoliciesCollector_Metrics(t *testing.T) {
+	tests := []struct {
+		name             string
+		viciClientErr    error
+		viciSessionErr   error
+		msgs             []*vici.Message
+		metricName       string
+		wantMetricsHelp  string
+		wantMetrics      string
+		wantMetricsCount int
+	}{
+		{
+			name:            "connection error",
+			viciClientErr:   errors.New("some error"),
+			metricName:      "swtest_policy_count",
+			wantMetricsHelp: "Number of installed policies of the type (trap, drop, pass)",
+			wantMetrics: `swtest_policy_count{type="drop"} 0
+swtest_policy_count{type="pass"} 0
+swtest_policy_count{type="trap"} 0
+`,
+			wantMetricsCount: 3,
+		},
+		{
+			name:            "session error",
+			viciSessionErr:  errors.New("some error"),
+			msgs:            []*vici.Message{policyMsg("home/net", "home", "net", "TUNNEL")},
+			metricName:      "swtest_policy_count",
+			wantMetricsHelp: "Number of installed policies of the type (trap, drop, pass)",
+			wantMetrics: `swtest_policy_count{type="drop"} 0
+swtest_policy_count{type="pass"} 0
+swtest_policy_count{type="trap"} 0
+`,
+			wantMetricsCount: 3,
+		},
+		{
+			name: "policy types",
+			msgs: []*vici.Message{
+				policyMsg("home/net", "home", "net", "TUNNEL"),
+				policyMsg("home/mgmt", "home", "mgmt", "TRANSPORT"),
+				policyMsg("lan", "", "lan", "PASS"),
+			},
+			metricName:      "swtest_policy_count",
+			wantMetricsHelp: "Number of installed policies of the type (trap, drop, pass)",
+			wantMetrics: `swtest_policy_count{type="drop"} 0
+swtest_policy_count{type="pass"} 1
+swtest_policy_count{type="trap"} 2
+`,
+			wantMetricsCount: 6,
+		},
+		{
+			name:            "trap policy info",
+			msgs:            []*vici.Message{policyMsg("home/net", "home", "net", "TUNNEL")},
+			metricName:      "swtest_policy_info",
+			wantMetricsHelp: "Installed trap or shunt policy with its mode and traffic selectors",
+			wantMetrics: `swtest_policy_info{child_name="net",ike_name="home",local_ts="10.1.0.0/16",mode="tunnel",policy_name="home/net",remote_ts="10.2.0.0/16;10.3.0.0/16",type="trap"} 1
+`,
+			wantMetricsCount: 4,
+		},
+		{
+			name:            "shunt policy info",
+			msgs:            []*vici.Message{policyMsg("block", "", "block", "DROP")},
+			metricName:      "swtest_policy_info",
+			wantMetricsHelp: "Installed trap or shunt policy with its mode and traffic selectors",
+			wantMetrics: `swtest_policy_info{child_name="block",ike_name="",local_ts="10.1.0.0/16",mode="drop",policy_name="block",remote_ts="10.2.0.0/16;10.3.0.0/16",type="drop"} 1
+`,
+			wantMetricsCount: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewPoliciesCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{policyMsgs: tt.msgs, err: tt.viciSessionErr}, tt.viciClientErr
+			})
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
+
+			wantMetricsContent := fmt.Sprintf(`# HELP %s %s
+# TYPE %s gauge
+%s`, tt.metricName, tt.wantMetricsHelp, tt.metricName, tt.wantMetrics)
+			if err := testutil.CollectAndCompare(c, strings.NewReader(wantMetricsContent), tt.metricName); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
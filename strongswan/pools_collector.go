@@ -0,0 +1,159 @@
+package strongswan
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+// PoolsCollector exposes the utilization of the virtual IP pools loaded via Vici.
+type PoolsCollector struct {
+	viciClientFn viciClientFn
+	withLeases   bool
+
+	poolCnt         *prometheus.Desc
+	poolSize        *prometheus.Desc
+	poolOnline      *prometheus.Desc
+	poolOffline     *prometheus.Desc
+	poolUtilization *prometheus.Desc
+	poolLease       *prometheus.Desc
+}
+
+func NewPoolsCollector(prefix string, viciClientFn viciClientFn, withLeases bool) prometheus.Collector {
+	return &PoolsCollector{
+		viciClientFn: viciClientFn,
+		withLeases:   withLeases,
+
+		poolCnt: prometheus.NewDesc(
+			prefix+"pool_count",
+			"Number of loaded virtual IP pools",
+			nil, nil,
+		),
+		poolSize: prometheus.NewDesc(
+			prefix+"pool_size",
+			"Number of addresses in the pool",
+			[]string{"pool_name"}, nil,
+		),
+		poolOnline: prometheus.NewDesc(
+			prefix+"pool_online_leases",
+			"Number of leases of the pool assigned to an established IKE SA",
+			[]string{"pool_name"}, nil,
+		),
+		poolOffline: prometheus.NewDesc(
+			prefix+"pool_offline_leases",
+			"Number of leases of the pool kept for identities which are offline",
+			[]string{"pool_name"}, nil,
+		),
+		poolUtilization: prometheus.NewDesc(
+			prefix+"pool_utilization_ratio",
+			"Ratio of online leases to the size of the pool",
+			[]string{"pool_name"}, nil,
+		),
+		poolLease: prometheus.NewDesc(
+			prefix+"pool_lease_info",
+			"Virtual IP lease of the pool with the assigned identity",
+			[]string{"pool_name", "address", "identity", "status"}, nil,
+		),
+	}
+}
+
+func (c *PoolsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.poolCnt
+	ch <- c.poolSize
+	ch <- c.poolOnline
+	ch <- c.poolOffline
+	ch <- c.poolUtilization
+	ch <- c.poolLease
+}
+
+func (c *PoolsCollector) Collect(ch chan<- prometheus.Metric) {
+	pools, err := c.listPools()
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.poolCnt,
+			prometheus.GaugeValue,
+			float64(0),
+		)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.poolCnt,
+		prometheus.GaugeValue,
+		float64(len(pools)),
+	)
+	for _, pool := range pools {
+		c.collectPoolMetrics(pool, ch)
+	}
+}
+
+func (c *PoolsCollector) collectPoolMetrics(pool Pool, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(
+		c.poolSize,
+		prometheus.GaugeValue,
+		float64(pool.Size),
+		pool.Name,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.poolOnline,
+		prometheus.GaugeValue,
+		float64(pool.Online),
+		pool.Name,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.poolOffline,
+		prometheus.GaugeValue,
+		float64(pool.Offline),
+		pool.Name,
+	)
+	if pool.Size > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.poolUtilization,
+			prometheus.GaugeValue,
+			float64(pool.Online)/float64(pool.Size),
+			pool.Name,
+		)
+	}
+	for _, lease := range pool.Leases {
+		ch <- prometheus.MustNewConstMetric(
+			c.poolLease,
+			prometheus.GaugeValue,
+			1,
+			pool.Name, lease.Address, lease.Identity, lease.Status,
+		)
+	}
+}
+
+func (c *PoolsCollector) listPools() ([]Pool, error) {
+	s, err := c.viciClientFn()
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	req := vici.NewMessage()
+	if c.withLeases {
+		if err := req.Set("leases", "yes"); err != nil {
+			return nil, err
+		}
+	}
+	m, err := s.CommandRequest("list-pools", req)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]Pool, 0, len(m.Keys()))
+	for _, name := range m.Keys() {
+		poolMsg, ok := m.Get(name).(*vici.Message)
+		if !ok {
+			continue
+		}
+		var pool Pool
+		if e := vici.UnmarshalMessage(poolMsg, &pool); e != nil {
+			log.Logger.Warnf("Message unmarshal error: %v", e)
+			continue
+		}
+		pool.Name = name
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
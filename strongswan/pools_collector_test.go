@@ -0,0 +1,136 @@
+package strongswan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func poolMsg(size int, online int, offline int, leases ...*vici.Message) *vici.Message {
+	m := vici.NewMessage()
+	m.Set("base", "10.3.0.1")
+	m.Set("size", size)
+	m.Set("online", online)
+	m.Set("offline", offline)
+	if len(leases) > 0 {
+		leasesMsg := vici.NewMessage()
+		for i, lease := range leases {
+			leasesMsg.Set(fmt.Sprint(i), lease)
+		}
+		m.Set("leases", leasesMsg)
+	}
+	return m
+}
+
+func leaseMsg(address string, identity string, status string) *vici.Message {
+	m := vici.NewMessage()
+	m.Set("address", address)
+	m.Set("identity", identity)
+	m.Set("status", status)
+	return m
+}
+
+func TestPoolsCollector_Metrics(t *testing.T) {
+	tests := []struct {
+		name              string
+		viciClientErr     error
+		withLeases        bool
+		pools             map[string]*vici.Message
+		metricName        string
+		wantMetricsHelp   string
+		wantMetricsLabels string
+		wantMetricsValue  float64
+		wantMetricsCount  int
+	}{
+		{
+			name:             "connection error",
+			viciClientErr:    errors.New("some error"),
+			metricName:       "swtest_pool_count",
+			wantMetricsHelp:  "Number of loaded virtual IP pools",
+			wantMetricsValue: 0,
+			wantMetricsCount: 1,
+		},
+		{
+			name:             "no pools",
+			pools:            map[string]*vici.Message{},
+			metricName:       "swtest_pool_count",
+			wantMetricsHelp:  "Number of loaded virtual IP pools",
+			wantMetricsValue: 0,
+			wantMetricsCount: 1,
+		},
+		{
+			name:              "pool size",
+			pools:             map[string]*vici.Message{"rw_pool": poolMsg(254, 3, 1)},
+			metricName:        "swtest_pool_size",
+			wantMetricsHelp:   "Number of addresses in the pool",
+			wantMetricsLabels: `pool_name="rw_pool"`,
+			wantMetricsValue:  254,
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "offline leases",
+			pools:             map[string]*vici.Message{"rw_pool": poolMsg(254, 3, 1)},
+			metricName:        "swtest_pool_offline_leases",
+			wantMetricsHelp:   "Number of leases of the pool kept for identities which are offline",
+			wantMetricsLabels: `pool_name="rw_pool"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  5,
+		},
+		{
+			name:              "utilization",
+			pools:             map[string]*vici.Message{"rw_pool": poolMsg(4, 3, 1)},
+			metricName:        "swtest_pool_utilization_ratio",
+			wantMetricsHelp:   "Ratio of online leases to the size of the pool",
+			wantMetricsLabels: `pool_name="rw_pool"`,
+			wantMetricsValue:  0.75,
+			wantMetricsCount:  5,
+		},
+		{
+			name:             "empty pool has no utilization",
+			pools:            map[string]*vici.Message{"rw_pool": poolMsg(0, 0, 0)},
+			metricName:       "swtest_pool_count",
+			wantMetricsHelp:  "Number of loaded virtual IP pools",
+			wantMetricsValue: 1,
+			wantMetricsCount: 4,
+		},
+		{
+			name:       "leases",
+			withLeases: true,
+			pools: map[string]*vici.Message{"rw_pool": poolMsg(254, 1, 0,
+				leaseMsg("10.3.0.1", "carol@strongswan.org", "online"),
+			)},
+			metricName:        "swtest_pool_lease_info",
+			wantMetricsHelp:   "Virtual IP lease of the pool with the assigned identity",
+			wantMetricsLabels: `address="10.3.0.1",identity="carol@strongswan.org",pool_name="rw_pool",status="online"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := vici.NewMessage()
+			for name, pool := range tt.pools {
+				msg.Set(name, pool)
+			}
+			c := NewPoolsCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{cmdMsgs: map[string]*vici.Message{"list-pools": msg}}, tt.viciClientErr
+			}, tt.withLeases)
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
+
+			wantMetricsContent := fmt.Sprintf(`# HELP %s %s
+# TYPE %s gauge
+%s{%s} %v
+`, tt.metricName, tt.wantMetricsHelp, tt.metricName, tt.metricName, tt.wantMetricsLabels, tt.wantMetricsValue)
+			if err := testutil.CollectAndCompare(c, strings.NewReader(wantMetricsContent), tt.metricName); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
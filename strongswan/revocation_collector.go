@@ -0,0 +1,494 @@
+package strongswan
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+const (
+	revocationMethodCrl  = "crl"
+	revocationMethodOcsp = "ocsp"
+
+	revocationGood    = "good"
+	revocationRevoked = "revoked"
+	revocationUnknown = "unknown"
+
+	// maxRevocationResponseSize limits the size of fetched CRLs and OCSP responses.
+	maxRevocationResponseSize = 16 << 20
+
+	// revocationRefreshInterval is the interval of the checks, the endpoints are only queried if the cache expired.
+	revocationRefreshInterval = time.Minute
+)
+
+var revocationStates = []string{revocationGood, revocationRevoked, revocationUnknown}
+
+// RevocationScope selects the loaded certificates of which the revocation state is checked.
+type RevocationScope string
+
+const (
+	// RevocationScopeLocal checks the certificates with a private key and the CA certificates.
+	RevocationScopeLocal RevocationScope = "local"
+	// RevocationScopeAll checks the end-entity certificates received from peers, which charon caches, as well.
+	RevocationScopeAll RevocationScope = "all"
+)
+
+func ParseRevocationScope(v string) (RevocationScope, error) {
+	switch RevocationScope(v) {
+	case RevocationScopeLocal, "":
+		return RevocationScopeLocal, nil
+	case RevocationScopeAll:
+		return RevocationScopeAll, nil
+	default:
+		return "", fmt.Errorf("unknown revocation scope: '%v'", v)
+	}
+}
+
+/*
+RevocationCollector checks the revocation status of the loaded X509 certificates. The CRLs of the HTTP
+distribution points are fetched and the HTTP OCSP responders of the certificates are queried with the own
+HTTP client of the exporter, independently of charon. CRLs and OCSP responses are only accepted if signed
+by the issuer of the certificate, which has to be loaded as well.
+
+The checks run in the background with Run, Collect only exports the result of the last check, so a slow
+endpoint does not delay the scrape. Fetched CRLs and OCSP responses, including failed fetches, are cached for
+the cache TTL or until their next update, so the endpoints are not queried on every check.
+*/
+type RevocationCollector struct {
+	viciClientFn viciClientFn
+	client       *http.Client
+	cacheTTL     time.Duration
+	scope        RevocationScope
+	now          func() time.Time
+
+	// only accessed by refresh
+	crls      map[string]cachedCrl
+	ocsps     map[string]cachedOcsp
+	endpoints map[revocationEndpoint]endpointStats
+
+	mu     sync.Mutex
+	result revocationResult
+
+	certRevocationState *prometheus.Desc
+	crlNextUpdate       *prometheus.Desc
+	endpointUp          *prometheus.Desc
+	endpointDuration    *prometheus.Desc
+}
+
+type cachedCrl struct {
+	crl     *x509.RevocationList
+	expires time.Time
+}
+
+type cachedOcsp struct {
+	resp    *ocspBasicResponse
+	expires time.Time
+}
+
+type revocationEndpoint struct {
+	method string
+	url    string
+}
+
+type endpointStats struct {
+	up       bool
+	duration time.Duration
+}
+
+// revocationResult is the result of the last check, which Collect exports.
+type revocationResult struct {
+	states         []certRevocationState
+	endpoints      map[revocationEndpoint]endpointStats
+	crlNextUpdates map[string]time.Time
+}
+
+type certRevocationState struct {
+	serialNumber string
+	subject      string
+	method       string
+	state        string
+}
+
+func NewRevocationCollector(prefix string, viciClientFn viciClientFn, client *http.Client, cacheTTL time.Duration, scope RevocationScope, now func() time.Time) *RevocationCollector {
+	return &RevocationCollector{
+		viciClientFn: viciClientFn,
+		client:       client,
+		cacheTTL:     cacheTTL,
+		scope:        scope,
+		now:          now,
+		crls:         make(map[string]cachedCrl),
+		ocsps:        make(map[string]cachedOcsp),
+		endpoints:    make(map[revocationEndpoint]endpointStats),
+
+		certRevocationState: prometheus.NewDesc(
+			prefix+"cert_revocation_state",
+			"Revocation state (good, revoked, unknown) of the X509 certificate by the method (crl, ocsp)",
+			[]string{"serial_number", "subject", "method", "state"}, nil,
+		),
+		crlNextUpdate: prometheus.NewDesc(
+			prefix+"revocation_crl_next_update_timestamp_seconds",
+			"Next update of the CRL fetched from the distribution point",
+			[]string{"url"}, nil,
+		),
+		endpointUp: prometheus.NewDesc(
+			prefix+"revocation_endpoint_up",
+			"Flag if the last request to the CRL distribution point or OCSP responder succeeded",
+			[]string{"method", "url"}, nil,
+		),
+		endpointDuration: prometheus.NewDesc(
+			prefix+"revocation_endpoint_request_duration_seconds",
+			"Duration of the last request to the CRL distribution point or OCSP responder",
+			[]string{"method", "url"}, nil,
+		),
+	}
+}
+
+func (c *RevocationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.certRevocationState
+	ch <- c.crlNextUpdate
+	ch <- c.endpointUp
+	ch <- c.endpointDuration
+}
+
+func (c *RevocationCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.result.states {
+		c.collectState(ch, s)
+	}
+	for ep, stats := range c.result.endpoints {
+		up := 0
+		if stats.up {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.endpointUp,
+			prometheus.GaugeValue,
+			float64(up),
+			ep.method, ep.url,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.endpointDuration,
+			prometheus.GaugeValue,
+			stats.duration.Seconds(),
+			ep.method, ep.url,
+		)
+	}
+	for url, nextUpdate := range c.result.crlNextUpdates {
+		ch <- prometheus.MustNewConstMetric(
+			c.crlNextUpdate,
+			prometheus.GaugeValue,
+			float64(nextUpdate.Unix()),
+			url,
+		)
+	}
+}
+
+func (c *RevocationCollector) collectState(ch chan<- prometheus.Metric, cs certRevocationState) {
+	for _, s := range revocationStates {
+		v := 0
+		if s == cs.state {
+			v = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.certRevocationState,
+			prometheus.GaugeValue,
+			float64(v),
+			cs.serialNumber, cs.subject, cs.method, s,
+		)
+	}
+}
+
+// Run checks the revocation state of the certificates until the context is canceled.
+func (c *RevocationCollector) Run(ctx context.Context) {
+	t := time.NewTicker(revocationRefreshInterval)
+	defer t.Stop()
+	for {
+		c.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// refresh checks the certificates of the scope, the result of the last check is kept if they cannot be listed.
+func (c *RevocationCollector) refresh(ctx context.Context) {
+	certs, err := c.listCerts()
+	if err != nil {
+		log.Logger.Warnf("Revocation check failed to list the certificates: %v", err)
+		return
+	}
+
+	now := c.now()
+	var cas []*x509.Certificate
+	for _, cert := range certs {
+		if isCACert(cert.vc, cert.cert) {
+			cas = append(cas, cert.cert)
+		}
+	}
+	chains := newCertChains(cas)
+	var states []certRevocationState
+	used := make(map[revocationEndpoint]bool)
+	usedOcsps := make(map[string]bool)
+	for _, lc := range certs {
+		cert := lc.cert
+		if isSelfSigned(cert) || !c.inScope(lc) {
+			continue
+		}
+		issuer := chains.issuer(cert)
+		state := func(method string, state string) certRevocationState {
+			return certRevocationState{formatSerialNumber(cert.SerialNumber), cert.Subject.String(), method, state}
+		}
+		if urls := httpURLs(cert.CRLDistributionPoints); len(urls) > 0 {
+			states = append(states, state(revocationMethodCrl, c.crlState(ctx, cert, issuer, urls, now)))
+			for _, url := range urls {
+				used[revocationEndpoint{revocationMethodCrl, url}] = true
+			}
+		}
+		if urls := httpURLs(cert.OCSPServer); len(urls) > 0 {
+			states = append(states, state(revocationMethodOcsp, c.ocspState(ctx, cert, issuer, urls, now)))
+			for _, url := range urls {
+				used[revocationEndpoint{revocationMethodOcsp, url}] = true
+				usedOcsps[ocspCacheKey(url, cert.SerialNumber)] = true
+			}
+		}
+	}
+	c.prune(used, usedOcsps)
+
+	result := revocationResult{
+		states:         states,
+		endpoints:      maps.Clone(c.endpoints),
+		crlNextUpdates: make(map[string]time.Time),
+	}
+	for url, cached := range c.crls {
+		if cached.crl != nil && !cached.crl.NextUpdate.IsZero() {
+			result.crlNextUpdates[url] = cached.crl.NextUpdate
+		}
+	}
+	c.mu.Lock()
+	c.result = result
+	c.mu.Unlock()
+}
+
+// inScope reports if the revocation state of the certificate is checked.
+func (c *RevocationCollector) inScope(lc listedCert) bool {
+	return c.scope == RevocationScopeAll || lc.vc.HasPrivkey == "yes" || isCACert(lc.vc, lc.cert)
+}
+
+/*
+prune removes the endpoints and cached responses which are not used by the checked certificates anymore. OCSP
+responses are about a single certificate, so they are removed with the certificate even if the responder is
+still used by others.
+*/
+func (c *RevocationCollector) prune(used map[revocationEndpoint]bool, usedOcsps map[string]bool) {
+	for ep := range c.endpoints {
+		if !used[ep] {
+			delete(c.endpoints, ep)
+		}
+	}
+	for url := range c.crls {
+		if !used[revocationEndpoint{revocationMethodCrl, url}] {
+			delete(c.crls, url)
+		}
+	}
+	for key := range c.ocsps {
+		if !usedOcsps[key] {
+			delete(c.ocsps, key)
+		}
+	}
+}
+
+// crlState is good if a CRL of the issuer does not list the certificate, and unknown if no such CRL is available.
+func (c *RevocationCollector) crlState(ctx context.Context, cert *x509.Certificate, issuer *x509.Certificate, urls []string, now time.Time) string {
+	if issuer == nil {
+		return revocationUnknown
+	}
+	state := revocationUnknown
+	for _, url := range urls {
+		crl := c.fetchCrl(ctx, url, now)
+		if crl == nil {
+			continue
+		}
+		if err := crl.CheckSignatureFrom(issuer); err != nil {
+			log.Logger.Warnf("CRL of %s signature error: %v", url, err)
+			continue
+		}
+		if slices.ContainsFunc(crl.RevokedCertificateEntries, func(e x509.RevocationListEntry) bool {
+			return e.SerialNumber.Cmp(cert.SerialNumber) == 0
+		}) {
+			return revocationRevoked
+		}
+		state = revocationGood
+	}
+	return state
+}
+
+// ocspState is the state of the first current OCSP response signed for the issuer about the certificate.
+func (c *RevocationCollector) ocspState(ctx context.Context, cert *x509.Certificate, issuer *x509.Certificate, urls []string, now time.Time) string {
+	if issuer == nil {
+		return revocationUnknown
+	}
+	req, err := newOcspRequest(cert, issuer)
+	if err != nil {
+		log.Logger.Warnf("OCSP request error: %v", err)
+		return revocationUnknown
+	}
+	for _, url := range urls {
+		resp := c.fetchOcsp(ctx, url, cert.SerialNumber, req, now)
+		if resp == nil {
+			continue
+		}
+		if err := resp.checkSignatureFrom(issuer); err != nil {
+			log.Logger.Warnf("OCSP response of %s signature error: %v", url, err)
+			continue
+		}
+		for _, sr := range resp.TBSResponseData.Responses {
+			if sr.CertID.SerialNumber.Cmp(cert.SerialNumber) != 0 || (!sr.NextUpdate.IsZero() && sr.NextUpdate.Before(now)) {
+				continue
+			}
+			if sr.Good {
+				return revocationGood
+			}
+			if !sr.Revoked.RevocationTime.IsZero() {
+				return revocationRevoked
+			}
+		}
+	}
+	return revocationUnknown
+}
+
+func (c *RevocationCollector) fetchCrl(ctx context.Context, url string, now time.Time) *x509.RevocationList {
+	if cached, ok := c.crls[url]; ok && now.Before(cached.expires) {
+		return cached.crl
+	}
+	var crl *x509.RevocationList
+	data, err := c.fetch(ctx, revocationMethodCrl, url, nil)
+	if err == nil {
+		crl, err = x509.ParseRevocationList(fileDER(data))
+	}
+	if err != nil {
+		log.Logger.Warnf("CRL fetch from %s failed: %v", url, err)
+		crl = nil
+	}
+	expires := now.Add(c.cacheTTL)
+	if crl != nil && crl.NextUpdate.After(now) && crl.NextUpdate.Before(expires) {
+		expires = crl.NextUpdate
+	}
+	c.crls[url] = cachedCrl{crl: crl, expires: expires}
+	return crl
+}
+
+func (c *RevocationCollector) fetchOcsp(ctx context.Context, url string, serialNumber *big.Int, req []byte, now time.Time) *ocspBasicResponse {
+	key := ocspCacheKey(url, serialNumber)
+	if cached, ok := c.ocsps[key]; ok && now.Before(cached.expires) {
+		return cached.resp
+	}
+	var resp *ocspBasicResponse
+	data, err := c.fetch(ctx, revocationMethodOcsp, url, req)
+	if err == nil {
+		resp, err = parseOcspBasicResponse(data)
+	}
+	if err != nil {
+		log.Logger.Warnf("OCSP request to %s failed: %v", url, err)
+		resp = nil
+	}
+	expires := now.Add(c.cacheTTL)
+	if resp != nil {
+		for _, sr := range resp.TBSResponseData.Responses {
+			if sr.NextUpdate.After(now) && sr.NextUpdate.Before(expires) {
+				expires = sr.NextUpdate
+			}
+		}
+	}
+	c.ocsps[key] = cachedOcsp{resp: resp, expires: expires}
+	return resp
+}
+
+// ocspCacheKey identifies the cached OCSP response of the responder about the certificate with the serial number.
+func ocspCacheKey(url string, serialNumber *big.Int) string {
+	return url + " " + serialNumber.Text(16)
+}
+
+// fetch gets the CRL or posts the OCSP request and records the reachability and latency of the endpoint.
+func (c *RevocationCollector) fetch(ctx context.Context, method string, url string, ocspReq []byte) ([]byte, error) {
+	start := time.Now()
+	data, err := c.request(ctx, url, ocspReq)
+	c.endpoints[revocationEndpoint{method, url}] = endpointStats{up: err == nil, duration: time.Since(start)}
+	return data, err
+}
+
+func (c *RevocationCollector) request(ctx context.Context, url string, ocspReq []byte) ([]byte, error) {
+	var req *http.Request
+	var err error
+	if ocspReq == nil {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(ocspReq))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/ocsp-request")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
+}
+
+// listedCert is a loaded X509 certificate together with its Vici flags.
+type listedCert struct {
+	vc   Cert
+	cert *x509.Certificate
+}
+
+func (c *RevocationCollector) listCerts() ([]listedCert, error) {
+	vcs, err := listCerts(c.viciClientFn)
+	if err != nil {
+		return nil, err
+	}
+	certs := make([]listedCert, 0, len(vcs))
+	for _, vc := range vcs {
+		if vc.Type != typeX509Cert {
+			continue
+		}
+		cert, err := x509.ParseCertificate([]byte(vc.Data))
+		if err != nil {
+			log.Logger.Warnf("Certificate parse error: %v", err)
+			continue
+		}
+		certs = append(certs, listedCert{vc: vc, cert: cert})
+	}
+	return certs, nil
+}
+
+// httpURLs returns the HTTP and HTTPS URIs, other schemes like LDAP are not supported.
+func httpURLs(uris []string) []string {
+	var urls []string
+	for _, uri := range uris {
+		if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+			urls = append(urls, uri)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,252 @@
+package strongswan
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+// fakeRevocationEndpoints stands in for the CRL distribution point and OCSP responder of the revocation test CA.
+type fakeRevocationEndpoints struct {
+	unavailable bool
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func (f *fakeRevocationEndpoints) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests[r.Host]++
+	f.mu.Unlock()
+	if f.unavailable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var file string
+	switch r.Host {
+	case "ca.revocation.example":
+		file = "testdata/revocation-ca.crl"
+	case "ocsp.revocation.example":
+		body, _ := io.ReadAll(r.Body)
+		var req ocspRequest
+		if _, err := asn1.Unmarshal(body, &req); err != nil || len(req.TBSRequest.RequestList) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch req.TBSRequest.RequestList[0].ReqCert.SerialNumber.Int64() {
+		case 0x401:
+			file = "testdata/revocation-ocsp-good.der"
+		case 0x402:
+			file = "testdata/revocation-ocsp-revoked.der"
+		}
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Write(data)
+}
+
+func newFakeRevocationEndpoints(t *testing.T, unavailable bool) (*fakeRevocationEndpoints, *http.Client) {
+	f := &fakeRevocationEndpoints{unavailable: unavailable, requests: make(map[string]int)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	proxyURL, _ := url.Parse(srv.URL)
+	return f, &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}, Timeout: time.Second}
+}
+
+func TestRevocationCollector_Metrics(t *testing.T) {
+	tests := []struct {
+		name        string
+		certs       []*vici.Message
+		scope       RevocationScope
+		unavailable bool
+		metricNames []string
+		wantMetrics string
+	}{
+		{
+			name:        "good certificate",
+			certs:       []*vici.Message{caCertMsg("testdata/revocation-ca.pem"), ownCertMsg("testdata/revocation-good.pem")},
+			metricNames: []string{"swtest_cert_revocation_state", "swtest_revocation_crl_next_update_timestamp_seconds", "swtest_revocation_endpoint_up"},
+			wantMetrics: `# HELP swtest_cert_revocation_state Revocation state (good, revoked, unknown) of the X509 certificate by the method (crl, ocsp)
+# TYPE swtest_cert_revocation_state gauge
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="good",subject="CN=good.revocation.example,O=Revocation,C=CH"} 1
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="revoked",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="unknown",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="good",subject="CN=good.revocation.example,O=Revocation,C=CH"} 1
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="revoked",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="unknown",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+# HELP swtest_revocation_crl_next_update_timestamp_seconds Next update of the CRL fetched from the distribution point
+# TYPE swtest_revocation_crl_next_update_timestamp_seconds gauge
+swtest_revocation_crl_next_update_timestamp_seconds{url="http://ca.revocation.example/ca.crl"} 1.7934912e+09
+# HELP swtest_revocation_endpoint_up Flag if the last request to the CRL distribution point or OCSP responder succeeded
+# TYPE swtest_revocation_endpoint_up gauge
+swtest_revocation_endpoint_up{method="crl",url="http://ca.revocation.example/ca.crl"} 1
+swtest_revocation_endpoint_up{method="ocsp",url="http://ocsp.revocation.example/"} 1
+`,
+		},
+		{
+			name:        "revoked certificate",
+			certs:       []*vici.Message{caCertMsg("testdata/revocation-ca.pem"), ownCertMsg("testdata/revocation-revoked.pem")},
+			metricNames: []string{"swtest_cert_revocation_state"},
+			wantMetrics: `# HELP swtest_cert_revocation_state Revocation state (good, revoked, unknown) of the X509 certificate by the method (crl, ocsp)
+# TYPE swtest_cert_revocation_state gauge
+swtest_cert_revocation_state{method="crl",serial_number="04:02",state="good",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="crl",serial_number="04:02",state="revoked",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 1
+swtest_cert_revocation_state{method="crl",serial_number="04:02",state="unknown",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:02",state="good",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:02",state="revoked",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 1
+swtest_cert_revocation_state{method="ocsp",serial_number="04:02",state="unknown",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 0
+`,
+		},
+		{
+			name:        "endpoints unavailable",
+			certs:       []*vici.Message{caCertMsg("testdata/revocation-ca.pem"), ownCertMsg("testdata/revocation-good.pem")},
+			unavailable: true,
+			metricNames: []string{"swtest_cert_revocation_state", "swtest_revocation_crl_next_update_timestamp_seconds", "swtest_revocation_endpoint_up"},
+			wantMetrics: `# HELP swtest_cert_revocation_state Revocation state (good, revoked, unknown) of the X509 certificate by the method (crl, ocsp)
+# TYPE swtest_cert_revocation_state gauge
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="good",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="revoked",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="unknown",subject="CN=good.revocation.example,O=Revocation,C=CH"} 1
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="good",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="revoked",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="unknown",subject="CN=good.revocation.example,O=Revocation,C=CH"} 1
+# HELP swtest_revocation_endpoint_up Flag if the last request to the CRL distribution point or OCSP responder succeeded
+# TYPE swtest_revocation_endpoint_up gauge
+swtest_revocation_endpoint_up{method="crl",url="http://ca.revocation.example/ca.crl"} 0
+swtest_revocation_endpoint_up{method="ocsp",url="http://ocsp.revocation.example/"} 0
+`,
+		},
+		{
+			name:        "issuer not loaded",
+			certs:       []*vici.Message{ownCertMsg("testdata/revocation-good.pem")},
+			metricNames: []string{"swtest_cert_revocation_state", "swtest_revocation_endpoint_up"},
+			wantMetrics: `# HELP swtest_cert_revocation_state Revocation state (good, revoked, unknown) of the X509 certificate by the method (crl, ocsp)
+# TYPE swtest_cert_revocation_state gauge
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="good",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="revoked",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="crl",serial_number="04:01",state="unknown",subject="CN=good.revocation.example,O=Revocation,C=CH"} 1
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="good",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="revoked",subject="CN=good.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:01",state="unknown",subject="CN=good.revocation.example,O=Revocation,C=CH"} 1
+`,
+		},
+		{
+			name:        "peer certificate",
+			certs:       []*vici.Message{caCertMsg("testdata/revocation-ca.pem"), x509CertMsg("testdata/revocation-revoked.pem")},
+			scope:       RevocationScopeLocal,
+			metricNames: []string{"swtest_cert_revocation_state", "swtest_revocation_endpoint_up"},
+			wantMetrics: "",
+		},
+		{
+			name:        "peer certificate with all scope",
+			certs:       []*vici.Message{caCertMsg("testdata/revocation-ca.pem"), x509CertMsg("testdata/revocation-revoked.pem")},
+			scope:       RevocationScopeAll,
+			metricNames: []string{"swtest_cert_revocation_state"},
+			wantMetrics: `# HELP swtest_cert_revocation_state Revocation state (good, revoked, unknown) of the X509 certificate by the method (crl, ocsp)
+# TYPE swtest_cert_revocation_state gauge
+swtest_cert_revocation_state{method="crl",serial_number="04:02",state="good",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="crl",serial_number="04:02",state="revoked",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 1
+swtest_cert_revocation_state{method="crl",serial_number="04:02",state="unknown",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:02",state="good",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 0
+swtest_cert_revocation_state{method="ocsp",serial_number="04:02",state="revoked",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 1
+swtest_cert_revocation_state{method="ocsp",serial_number="04:02",state="unknown",subject="CN=revoked.revocation.example,O=Revocation,C=CH"} 0
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newFakeRevocationEndpoints(t, tt.unavailable)
+			scope := tt.scope
+			if scope == "" {
+				scope = RevocationScopeLocal
+			}
+			c := NewRevocationCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{certMsgs: tt.certs}, nil
+			}, client, time.Hour, scope, func() time.Time {
+				return time.Unix(1792108800, 0) // 2026-10-16T00:00:00Z
+			})
+			c.refresh(context.Background())
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.wantMetrics), tt.metricNames...); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
+
+func TestRevocationCollector_Cache(t *testing.T) {
+	endpoints, client := newFakeRevocationEndpoints(t, false)
+	msgs := []*vici.Message{caCertMsg("testdata/revocation-ca.pem"), ownCertMsg("testdata/revocation-good.pem")}
+	now := time.Unix(1792108800, 0) // 2026-10-16T00:00:00Z
+	c := NewRevocationCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{certMsgs: msgs}, nil
+	}, client, time.Hour, RevocationScopeLocal, func() time.Time {
+		return now
+	})
+
+	c.refresh(context.Background())
+	c.refresh(context.Background())
+	require.Equal(t, map[string]int{"ca.revocation.example": 1, "ocsp.revocation.example": 1}, endpoints.requests)
+
+	// the scrapes only export the result of the last check
+	testutil.CollectAndCount(c)
+	require.Equal(t, map[string]int{"ca.revocation.example": 1, "ocsp.revocation.example": 1}, endpoints.requests)
+
+	now = now.Add(time.Hour)
+	c.refresh(context.Background())
+	require.Equal(t, map[string]int{"ca.revocation.example": 2, "ocsp.revocation.example": 2}, endpoints.requests)
+}
+
+func TestRevocationCollector_PruneOcspCache(t *testing.T) {
+	_, client := newFakeRevocationEndpoints(t, false)
+	msgs := []*vici.Message{caCertMsg("testdata/revocation-ca.pem"), ownCertMsg("testdata/revocation-good.pem"), ownCertMsg("testdata/revocation-revoked.pem")}
+	c := NewRevocationCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{certMsgs: msgs}, nil
+	}, client, time.Hour, RevocationScopeLocal, func() time.Time {
+		return time.Unix(1792108800, 0) // 2026-10-16T00:00:00Z
+	})
+
+	c.refresh(context.Background())
+	require.Len(t, c.ocsps, 2)
+
+	// the response about the removed certificate is dropped, although the responder is still used
+	msgs = msgs[:2]
+	c.refresh(context.Background())
+	require.Len(t, c.ocsps, 1)
+	require.Contains(t, c.ocsps, "http://ocsp.revocation.example/ 401")
+}
+
+func TestRevocationCollector_ListError(t *testing.T) {
+	_, client := newFakeRevocationEndpoints(t, false)
+	var viciClientErr error
+	c := NewRevocationCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{certMsgs: []*vici.Message{caCertMsg("testdata/revocation-ca.pem"), ownCertMsg("testdata/revocation-good.pem")}}, viciClientErr
+	}, client, time.Hour, RevocationScopeLocal, func() time.Time {
+		return time.Unix(1792108800, 0) // 2026-10-16T00:00:00Z
+	})
+
+	c.refresh(context.Background())
+	require.Equal(t, 6, testutil.CollectAndCount(c, "swtest_cert_revocation_state"))
+
+	// the result of the last check is kept
+	viciClientErr = errors.New("some error")
+	c.refresh(context.Background())
+	require.Equal(t, 6, testutil.CollectAndCount(c, "swtest_cert_revocation_state"))
+}
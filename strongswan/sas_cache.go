@@ -0,0 +1,421 @@
+package strongswan
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+const (
+	eventIkeUpdown   = "ike-updown"
+	eventIkeRekey    = "ike-rekey"
+	eventIkeUpdate   = "ike-update"
+	eventChildUpdown = "child-updown"
+	eventChildRekey  = "child-rekey"
+)
+
+var saCacheEvents = []string{eventIkeUpdown, eventIkeRekey, eventIkeUpdate, eventChildUpdown, eventChildRekey}
+
+/*
+SasCache maintains the IKE and CHILD SAs from the ike-updown, ike-rekey, ike-update, child-updown and
+child-rekey events, so that scrapes do not have to list all SAs. The cache is replaced by a full list-sas
+after the reconcile interval and whenever the event stream is re-established. Events received while list-sas
+is in flight are replayed on its result, as it may not include them.
+
+Durations and traffic counters of cached SAs are those of the last event, durations are shifted by the
+time elapsed since then. The traffic counters therefore only change with events and reconciles.
+*/
+type SasCache struct {
+	viciClientFn      viciClientFn
+	now               func() time.Time
+	reconcileInterval time.Duration
+
+	// reconcileMu serializes the reconciles, so concurrent scrapes do not list the SAs twice.
+	reconcileMu sync.Mutex
+
+	mu  sync.Mutex
+	sas map[string]*cachedIkeSa
+	// pending are the events received during a reconcile, nil while none is in flight.
+	pending       []pendingEvent
+	synced        bool
+	lastReconcile time.Time
+	drift         int
+	reconciles    int
+	reconcileErrs int
+	events        map[string]int
+
+	cacheAge      *prometheus.Desc
+	cacheSize     *prometheus.Desc
+	driftCnt      *prometheus.Desc
+	reconcileCnt  *prometheus.Desc
+	reconcileErrC *prometheus.Desc
+	eventCnt      *prometheus.Desc
+}
+
+type cachedIkeSa struct {
+	ikeSa    IkeSa
+	updated  time.Time
+	children map[string]cachedChildSa
+}
+
+type cachedChildSa struct {
+	childSa ChildIkeSa
+	updated time.Time
+}
+
+type pendingEvent struct {
+	e        vici.Event
+	received time.Time
+}
+
+func NewSasCache(prefix string, viciClientFn viciClientFn, reconcileInterval time.Duration, now func() time.Time) *SasCache {
+	return &SasCache{
+		viciClientFn:      viciClientFn,
+		now:               now,
+		reconcileInterval: reconcileInterval,
+		sas:               make(map[string]*cachedIkeSa),
+		events:            make(map[string]int),
+
+		cacheAge: prometheus.NewDesc(
+			prefix+"sa_cache_age_seconds",
+			"Seconds since the SA cache was last reconciled with list-sas",
+			nil, nil,
+		),
+		cacheSize: prometheus.NewDesc(
+			prefix+"sa_cache_ike_count",
+			"Number of IKEs in the SA cache",
+			nil, nil,
+		),
+		driftCnt: prometheus.NewDesc(
+			prefix+"sa_cache_reconcile_drift",
+			"Number of IKE and CHILD SAs which differed between the SA cache and list-sas at the last reconcile",
+			nil, nil,
+		),
+		reconcileCnt: prometheus.NewDesc(
+			prefix+"sa_cache_reconciles_total",
+			"Number of SA cache reconciles",
+			nil, nil,
+		),
+		reconcileErrC: prometheus.NewDesc(
+			prefix+"sa_cache_reconcile_errors_total",
+			"Number of failed SA cache reconciles",
+			nil, nil,
+		),
+		eventCnt: prometheus.NewDesc(
+			prefix+"sa_cache_events_total",
+			"Number of Vici events applied to the SA cache",
+			[]string{"event"}, nil,
+		),
+	}
+}
+
+func (c *SasCache) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cacheAge
+	ch <- c.cacheSize
+	ch <- c.driftCnt
+	ch <- c.reconcileCnt
+	ch <- c.reconcileErrC
+	ch <- c.eventCnt
+}
+
+func (c *SasCache) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.lastReconcile.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			c.cacheAge,
+			prometheus.GaugeValue,
+			c.now().Sub(c.lastReconcile).Seconds(),
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.cacheSize,
+		prometheus.GaugeValue,
+		float64(len(c.sas)),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.driftCnt,
+		prometheus.GaugeValue,
+		float64(c.drift),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.reconcileCnt,
+		prometheus.CounterValue,
+		float64(c.reconciles),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.reconcileErrC,
+		prometheus.CounterValue,
+		float64(c.reconcileErrs),
+	)
+	for _, e := range saCacheEvents {
+		ch <- prometheus.MustNewConstMetric(
+			c.eventCnt,
+			prometheus.CounterValue,
+			float64(c.events[e]),
+			e,
+		)
+	}
+}
+
+/*
+list returns the cached SAs, reconciling them first if they are due. If the reconcile fails, the cached SAs are
+served and the reconcile is retried on the next scrape, only a cache which was never reconciled fails.
+*/
+func (c *SasCache) list() ([]IkeSa, error) {
+	err := c.reconcileIfDue()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil && c.reconciles == 0 {
+		return nil, err
+	}
+
+	now := c.now()
+	res := make([]IkeSa, 0, len(c.sas))
+	for _, cached := range c.sas {
+		ikeSa := agedIkeSa(cached.ikeSa, now.Sub(cached.updated))
+		ikeSa.Children = make(map[string]ChildIkeSa, len(cached.children))
+		for id, child := range cached.children {
+			ikeSa.Children[id] = agedChildSa(child.childSa, now.Sub(child.updated))
+		}
+		res = append(res, ikeSa)
+	}
+	return res, nil
+}
+
+func (c *SasCache) reconcileIfDue() error {
+	c.reconcileMu.Lock()
+	defer c.reconcileMu.Unlock()
+
+	c.mu.Lock()
+	due := !c.synced || c.now().Sub(c.lastReconcile) >= c.reconcileInterval
+	c.mu.Unlock()
+
+	if !due {
+		return nil
+	}
+	return c.reconcile()
+}
+
+func (c *SasCache) reconcile() error {
+	c.mu.Lock()
+	c.pending = make([]pendingEvent, 0)
+	c.mu.Unlock()
+
+	sas, err := listSas(c.viciClientFn)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending := c.pending
+	c.pending = nil
+	if err != nil {
+		c.reconcileErrs++
+		log.Logger.Warnf("SA cache reconcile failed: %v", err)
+		return err
+	}
+
+	now := c.now()
+	fresh := make(map[string]*cachedIkeSa, len(sas))
+	for _, ikeSa := range sas {
+		cached := &cachedIkeSa{updated: now, children: make(map[string]cachedChildSa, len(ikeSa.Children))}
+		for _, child := range ikeSa.Children {
+			cached.children[child.UniqueID] = cachedChildSa{childSa: child, updated: now}
+		}
+		ikeSa.Children = nil
+		cached.ikeSa = ikeSa
+		fresh[ikeSa.UniqueID] = cached
+	}
+
+	if c.reconciles > 0 {
+		c.drift = saDrift(c.sas, fresh)
+		if c.drift > 0 {
+			log.Logger.Debugf("SA cache drifted by %d SAs since the last reconcile.", c.drift)
+		}
+	}
+	c.sas = fresh
+	for _, p := range pending {
+		c.applyEvent(p.e, p.received)
+	}
+	c.synced = true
+	c.lastReconcile = now
+	c.reconciles++
+	return nil
+}
+
+func (c *SasCache) resync() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.synced = false
+}
+
+func (c *SasCache) handleEvent(e vici.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events[e.Name]++
+	now := c.now()
+	if c.pending != nil {
+		c.pending = append(c.pending, pendingEvent{e: e, received: now})
+	}
+	c.applyEvent(e, now)
+}
+
+func (c *SasCache) applyEvent(e vici.Event, now time.Time) {
+	up := e.Message.Get("up") == "yes"
+
+	switch e.Name {
+	case eventIkeUpdown:
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			if up {
+				c.updateIke(ikeSa, now)
+			} else {
+				delete(c.sas, ikeSa.UniqueID)
+			}
+		}
+	case eventIkeUpdate:
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			c.updateIke(ikeSa, now)
+		}
+	case eventIkeRekey:
+		for _, name := range e.Message.Keys() {
+			oldSa, newSa, err := unmarshalRekey[IkeSa](e.Message, name)
+			if err != nil {
+				log.Logger.Warnf("Message unmarshal error: %v", err)
+				continue
+			}
+			newSa.Name = name
+			cached := c.updateIke(newSa, now)
+			// CHILD SAs are migrated to the new IKE SA
+			if old, ok := c.sas[oldSa.UniqueID]; ok {
+				for id, child := range old.children {
+					cached.children[id] = child
+				}
+				delete(c.sas, oldSa.UniqueID)
+			}
+		}
+	case eventChildUpdown:
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			cached := c.updateIke(ikeSa, now)
+			for _, child := range ikeSa.Children {
+				if up {
+					cached.children[child.UniqueID] = cachedChildSa{childSa: child, updated: now}
+				} else {
+					delete(cached.children, child.UniqueID)
+				}
+			}
+		}
+	case eventChildRekey:
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			cached := c.updateIke(ikeSa, now)
+			childSas, ok := e.Message.Get(ikeSa.Name).(*vici.Message).Get("child-sas").(*vici.Message)
+			if !ok {
+				continue
+			}
+			for _, name := range childSas.Keys() {
+				oldSa, newSa, err := unmarshalRekey[ChildIkeSa](childSas, name)
+				if err != nil {
+					log.Logger.Warnf("Message unmarshal error: %v", err)
+					continue
+				}
+				delete(cached.children, oldSa.UniqueID)
+				cached.children[newSa.UniqueID] = cachedChildSa{childSa: newSa, updated: now}
+			}
+		}
+	}
+}
+
+// updateIke replaces the IKE SA attributes and keeps the known CHILD SAs.
+func (c *SasCache) updateIke(ikeSa IkeSa, now time.Time) *cachedIkeSa {
+	cached, ok := c.sas[ikeSa.UniqueID]
+	if !ok {
+		cached = &cachedIkeSa{children: make(map[string]cachedChildSa)}
+		c.sas[ikeSa.UniqueID] = cached
+	}
+	ikeSa.Children = nil
+	cached.ikeSa = ikeSa
+	cached.updated = now
+	return cached
+}
+
+// unmarshalRekey returns the old and new SA of the section with the given key in a rekey event.
+func unmarshalRekey[T IkeSa | ChildIkeSa](m *vici.Message, key string) (T, T, error) {
+	var oldSa, newSa T
+	section, ok := m.Get(key).(*vici.Message)
+	if !ok {
+		return oldSa, newSa, errors.New("missing rekey section " + key)
+	}
+	oldMsg, okOld := section.Get("old").(*vici.Message)
+	newMsg, okNew := section.Get("new").(*vici.Message)
+	if !okOld || !okNew {
+		return oldSa, newSa, errors.New("missing old or new SA in rekey section " + key)
+	}
+	if err := vici.UnmarshalMessage(oldMsg, &oldSa); err != nil {
+		return oldSa, newSa, err
+	}
+	if err := vici.UnmarshalMessage(newMsg, &newSa); err != nil {
+		return oldSa, newSa, err
+	}
+	return oldSa, newSa, nil
+}
+
+// saDrift counts the IKE and CHILD SAs which are missing in either of the caches or have a different state.
+func saDrift(cached map[string]*cachedIkeSa, fresh map[string]*cachedIkeSa) int {
+	drift := 0
+	for id, f := range fresh {
+		c, ok := cached[id]
+		if !ok {
+			drift += 1 + len(f.children)
+			continue
+		}
+		if c.ikeSa.State != f.ikeSa.State {
+			drift++
+		}
+		for childID, fc := range f.children {
+			if cc, ok := c.children[childID]; !ok || cc.childSa.State != fc.childSa.State {
+				drift++
+			}
+		}
+		for childID := range c.children {
+			if _, ok := f.children[childID]; !ok {
+				drift++
+			}
+		}
+	}
+	for id, c := range cached {
+		if _, ok := fresh[id]; !ok {
+			drift += 1 + len(c.children)
+		}
+	}
+	return drift
+}
+
+func agedIkeSa(ikeSa IkeSa, age time.Duration) IkeSa {
+	secs := int64(age.Seconds())
+	ikeSa.EstablishSec += secs
+	ikeSa.RekeySec = max(ikeSa.RekeySec-secs, 0)
+	ikeSa.ReauthSec = max(ikeSa.ReauthSec-secs, 0)
+	return ikeSa
+}
+
+func agedChildSa(childSa ChildIkeSa, age time.Duration) ChildIkeSa {
+	secs := int64(age.Seconds())
+	childSa.EstablishSec += secs
+	childSa.RekeySec = max(childSa.RekeySec-secs, 0)
+	childSa.LifetimeSec = max(childSa.LifetimeSec-secs, 0)
+	if childSa.PacketsIn > 0 {
+		childSa.LastInSec += secs
+	}
+	if childSa.PacketsOut > 0 {
+		childSa.LastOutSec += secs
+	}
+	return childSa
+}
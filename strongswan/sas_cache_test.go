@@ -0,0 +1,221 @@
+package strongswan
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func ikeSaMsg(uniqueID string, state string, children map[string]*vici.Message) *vici.Message {
+	m := vici.NewMessage()
+	m.Set("uniqueid", uniqueID)
+	m.Set("state", state)
+	m.Set("established", 10)
+	if len(children) > 0 {
+		childSas := vici.NewMessage()
+		for k, child := range children {
+			childSas.Set(k, child)
+		}
+		m.Set("child-sas", childSas)
+	}
+	return m
+}
+
+func childSaMsg(name string, uniqueID string, state string) *vici.Message {
+	m := vici.NewMessage()
+	m.Set("name", name)
+	m.Set("uniqueid", uniqueID)
+	m.Set("state", state)
+	m.Set("install-time", 5)
+	m.Set("life-time", 100)
+	return m
+}
+
+func rekeyMsg(oldMsg *vici.Message, newMsg *vici.Message) *vici.Message {
+	m := vici.NewMessage()
+	m.Set("old", oldMsg)
+	m.Set("new", newMsg)
+	return m
+}
+
+func eventMsg(up bool, name string, section *vici.Message) *vici.Message {
+	m := vici.NewMessage()
+	if up {
+		m.Set("up", "yes")
+	}
+	m.Set(name, section)
+	return m
+}
+
+// cachedSaIDs returns the sorted "ike-id/child-id" pairs of the listed SAs.
+func cachedSaIDs(t *testing.T, c *SasCache) []string {
+	sas, err := c.list()
+	require.NoError(t, err)
+	var ids []string
+	for _, ikeSa := range sas {
+		ids = append(ids, ikeSa.Name+":"+ikeSa.UniqueID)
+		for _, child := range ikeSa.Children {
+			ids = append(ids, ikeSa.UniqueID+"/"+child.UniqueID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSasCache_Events(t *testing.T) {
+	now := time.Unix(1000, 0)
+	listMsg := vici.NewMessage()
+	listMsg.Set("ike-a", ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{"net-1": childSaMsg("net", "1", "INSTALLED")}))
+	fvc := &fakeViciClient{saMsgs: []*vici.Message{listMsg}}
+	c := NewSasCache("swtest_", func() (ViciClient, error) {
+		return fvc, nil
+	}, time.Minute, func() time.Time {
+		return now
+	})
+
+	require.Equal(t, []string{"1/1", "ike-a:1"}, cachedSaIDs(t, c), "initial reconcile")
+
+	// list-sas is not consulted again within the reconcile interval
+	fvc.saMsgs = nil
+
+	c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(true, "ike-b", ikeSaMsg("2", "ESTABLISHED", nil))})
+	c.handleEvent(vici.Event{Name: eventChildUpdown, Message: eventMsg(true, "ike-b", ikeSaMsg("2", "ESTABLISHED", map[string]*vici.Message{"net-2": childSaMsg("net", "2", "INSTALLED")}))})
+	require.Equal(t, []string{"1/1", "2/2", "ike-a:1", "ike-b:2"}, cachedSaIDs(t, c), "IKE and CHILD SA up")
+
+	childRekey := ikeSaMsg("2", "ESTABLISHED", map[string]*vici.Message{"net": rekeyMsg(childSaMsg("net", "2", "REKEYED"), childSaMsg("net", "3", "INSTALLED"))})
+	c.handleEvent(vici.Event{Name: eventChildRekey, Message: eventMsg(false, "ike-b", childRekey)})
+	require.Equal(t, []string{"1/1", "2/3", "ike-a:1", "ike-b:2"}, cachedSaIDs(t, c), "CHILD SA rekeyed")
+
+	ikeRekey := vici.NewMessage()
+	ikeRekey.Set("ike-b", rekeyMsg(ikeSaMsg("2", "REKEYED", nil), ikeSaMsg("4", "ESTABLISHED", nil)))
+	c.handleEvent(vici.Event{Name: eventIkeRekey, Message: ikeRekey})
+	require.Equal(t, []string{"1/1", "4/3", "ike-a:1", "ike-b:4"}, cachedSaIDs(t, c), "IKE SA rekeyed with migrated CHILD SA")
+
+	c.handleEvent(vici.Event{Name: eventChildUpdown, Message: eventMsg(false, "ike-a", ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{"net-1": childSaMsg("net", "1", "DELETING")}))})
+	c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(false, "ike-b", ikeSaMsg("4", "DELETING", nil))})
+	require.Equal(t, []string{"ike-a:1"}, cachedSaIDs(t, c), "IKE and CHILD SA down")
+
+	now = now.Add(30 * time.Second)
+	sas, err := c.list()
+	require.NoError(t, err)
+	require.Len(t, sas, 1)
+	require.Equal(t, int64(40), sas[0].EstablishSec, "established seconds shifted by the cache age")
+}
+
+func TestSasCache_Reconcile(t *testing.T) {
+	now := time.Unix(1000, 0)
+	listMsg := vici.NewMessage()
+	listMsg.Set("ike-a", ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{"net-1": childSaMsg("net", "1", "INSTALLED")}))
+	fvc := &fakeViciClient{saMsgs: []*vici.Message{listMsg}}
+	c := NewSasCache("swtest_", func() (ViciClient, error) {
+		return fvc, nil
+	}, time.Minute, func() time.Time {
+		return now
+	})
+	require.Equal(t, []string{"1/1", "ike-a:1"}, cachedSaIDs(t, c))
+
+	// A missed child-updown and ike-updown event are corrected on the next reconcile.
+	listMsg = vici.NewMessage()
+	listMsg.Set("ike-a", ikeSaMsg("1", "ESTABLISHED", nil))
+	listMsg.Set("ike-b", ikeSaMsg("2", "ESTABLISHED", nil))
+	fvc.saMsgs = []*vici.Message{listMsg}
+	now = now.Add(time.Minute)
+	require.Equal(t, []string{"ike-a:1", "ike-b:2"}, cachedSaIDs(t, c))
+
+	// The cache is reconciled after the event stream was re-established.
+	listMsg = vici.NewMessage()
+	fvc.saMsgs = []*vici.Message{listMsg}
+	c.resync()
+	require.Empty(t, cachedSaIDs(t, c))
+
+	// A failed reconcile serves the cached SAs.
+	fvc.err = errors.New("some error")
+	c.resync()
+	sas, err := c.list()
+	require.NoError(t, err)
+	require.Empty(t, sas)
+
+	c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(true, "ike-c", ikeSaMsg("3", "ESTABLISHED", nil))})
+	now = now.Add(10 * time.Second)
+
+	want := `# HELP swtest_sa_cache_age_seconds Seconds since the SA cache was last reconciled with list-sas
+# TYPE swtest_sa_cache_age_seconds gauge
+swtest_sa_cache_age_seconds 10
+# HELP swtest_sa_cache_events_total Number of Vici events applied to the SA cache
+# TYPE swtest_sa_cache_events_total counter
+swtest_sa_cache_events_total{event="child-rekey"} 0
+swtest_sa_cache_events_total{event="child-updown"} 0
+swtest_sa_cache_events_total{event="ike-rekey"} 0
+swtest_sa_cache_events_total{event="ike-update"} 0
+swtest_sa_cache_events_total{event="ike-updown"} 1
+# HELP swtest_sa_cache_ike_count Number of IKEs in the SA cache
+# TYPE swtest_sa_cache_ike_count gauge
+swtest_sa_cache_ike_count 1
+# HELP swtest_sa_cache_reconcile_drift Number of IKE and CHILD SAs which differed between the SA cache and list-sas at the last reconcile
+# TYPE swtest_sa_cache_reconcile_drift gauge
+swtest_sa_cache_reconcile_drift 2
+# HELP swtest_sa_cache_reconcile_errors_total Number of failed SA cache reconciles
+# TYPE swtest_sa_cache_reconcile_errors_total counter
+swtest_sa_cache_reconcile_errors_total 1
+# HELP swtest_sa_cache_reconciles_total Number of SA cache reconciles
+# TYPE swtest_sa_cache_reconciles_total counter
+swtest_sa_cache_reconciles_total 3
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestSasCache_NeverReconciled(t *testing.T) {
+	c := NewSasCache("swtest_", func() (ViciClient, error) {
+		return nil, errors.New("some error")
+	}, time.Minute, time.Now)
+	c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(true, "ike-a", ikeSaMsg("1", "ESTABLISHED", nil))})
+
+	// The SAs of the events alone are incomplete.
+	_, err := c.list()
+	require.Error(t, err)
+}
+
+func TestSasCache_EventsDuringReconcile(t *testing.T) {
+	listMsg := vici.NewMessage()
+	listMsg.Set("ike-a", ikeSaMsg("1", "ESTABLISHED", nil))
+	fvc := &fakeViciClient{saMsgs: []*vici.Message{listMsg}}
+	var c *SasCache
+	c = NewSasCache("swtest_", func() (ViciClient, error) {
+		// The events arrive after charon compiled the list-sas result.
+		c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(false, "ike-a", ikeSaMsg("1", "DELETING", nil))})
+		c.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(true, "ike-b", ikeSaMsg("2", "ESTABLISHED", nil))})
+		return fvc, nil
+	}, time.Minute, time.Now)
+
+	require.Equal(t, []string{"ike-b:2"}, cachedSaIDs(t, c), "events replayed on the list-sas result")
+}
+
+func TestSasCollector_Cache(t *testing.T) {
+	listMsg := vici.NewMessage()
+	listMsg.Set("ike-a", ikeSaMsg("1", "ESTABLISHED", nil))
+	fvc := &fakeViciClient{saMsgs: []*vici.Message{listMsg}}
+	viciClientFn := func() (ViciClient, error) {
+		return fvc, nil
+	}
+	cache := NewSasCache("swtest_", viciClientFn, time.Minute, time.Now)
+	c := NewSasCollector("swtest_", viciClientFn, cache, nil, time.Now, MetricsSchemaV1)
+
+	require.Equal(t, 24, testutil.CollectAndCount(c), "metrics count")
+
+	fvc.saMsgs = nil
+	cache.handleEvent(vici.Event{Name: eventIkeUpdown, Message: eventMsg(true, "ike-b", ikeSaMsg("2", "ESTABLISHED", nil))})
+	if err := testutil.CollectAndCompare(c, strings.NewReader(`# HELP swtest_ike_count Number of known IKEs
+# TYPE swtest_ike_count gauge
+swtest_ike_count 2
+`), "swtest_ike_count"); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
@@ -1,7 +1,10 @@
 package strongswan
 
 import (
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/strongswan/govici/vici"
@@ -17,12 +20,52 @@ const (
 	unknown               connectionStatus = 3
 )
 
+// ikeStates and childStates are the IKE_SA and CHILD_SA states of strongSwan, exported as StateSets.
+var (
+	ikeStates   = []string{"CREATED", "CONNECTING", "ESTABLISHED", "PASSIVE", "REKEYING", "REKEYED", "DELETING", "DESTROYING"}
+	childStates = []string{"CREATED", "ROUTED", "INSTALLING", "INSTALLED", "UPDATING", "REKEYING", "REKEYED", "RETRYING", "DELETING", "DELETED", "DESTROYING"}
+)
+
+// ikeInfoLabels are the optional labels of the IKE info metric in their exported order.
+var ikeInfoLabels = []string{
+	"local_host", "local_port", "local_id", "remote_host", "remote_port", "remote_id",
+	"initiator_spi", "responder_spi", "encr_alg", "encr_keysize", "integ_alg", "integ_keysize", "prf_alg", "dh_group",
+}
+
+// DefaultIkeInfoLabels leaves out the SPIs, which change with every rekey.
+var DefaultIkeInfoLabels = []string{
+	"local_host", "local_port", "local_id", "remote_host", "remote_port", "remote_id",
+	"encr_alg", "encr_keysize", "integ_alg", "integ_keysize", "prf_alg", "dh_group",
+}
+
+var ikeInfoLabelValues = map[string]func(IkeSa) string{
+	"local_host":    func(s IkeSa) string { return s.LocalHost },
+	"local_port":    func(s IkeSa) string { return strconv.Itoa(s.LocalPort) },
+	"local_id":      func(s IkeSa) string { return s.LocalID },
+	"remote_host":   func(s IkeSa) string { return s.RemoteHost },
+	"remote_port":   func(s IkeSa) string { return strconv.Itoa(s.RemotePort) },
+	"remote_id":     func(s IkeSa) string { return s.RemoteID },
+	"initiator_spi": func(s IkeSa) string { return s.InitiatorSpi },
+	"responder_spi": func(s IkeSa) string { return s.ResponderSpi },
+	"encr_alg":      func(s IkeSa) string { return s.EncAlg },
+	"encr_keysize":  func(s IkeSa) string { return strconv.Itoa(s.EncKey) },
+	"integ_alg":     func(s IkeSa) string { return s.IntegAlg },
+	"integ_keysize": func(s IkeSa) string { return strconv.Itoa(s.IntegKey) },
+	"prf_alg":       func(s IkeSa) string { return s.PrfAlg },
+	"dh_group":      func(s IkeSa) string { return s.DHGroup },
+}
+
 type SasCollector struct {
 	viciClientFn viciClientFn
+	cache        *SasCache
+	infoLabels   []string
+	now          func() time.Time
+	schema       MetricsSchema
 
 	ikeCnt           *prometheus.Desc
 	ikeVersion       *prometheus.Desc
 	ikeStatus        *prometheus.Desc
+	ikeState         *prometheus.Desc
 	ikeInitiator     *prometheus.Desc
 	ikeNatLocal      *prometheus.Desc
 	ikeNatRemote     *prometheus.Desc
@@ -34,8 +77,15 @@ type SasCollector struct {
 	ikeRekeySecs     *prometheus.Desc
 	ikeReauthSecs    *prometheus.Desc
 	ikeChildren      *prometheus.Desc
+	ikeInfo          *prometheus.Desc
+
+	ikeEstablishedTs *prometheus.Desc
+	ikeRekeyTs       *prometheus.Desc
+	ikeReauthTs      *prometheus.Desc
 
 	saStatus        *prometheus.Desc
+	saState         *prometheus.Desc
+	saInfo          *prometheus.Desc
 	saEncap         *prometheus.Desc
 	saEncKeySize    *prometheus.Desc
 	saIntegKeySize  *prometheus.Desc
@@ -48,11 +98,45 @@ type SasCollector struct {
 	saEstablishSecs *prometheus.Desc
 	saRekeySecs     *prometheus.Desc
 	saLifetimeSecs  *prometheus.Desc
+
+	saLastInTs      *prometheus.Desc
+	saLastOutTs     *prometheus.Desc
+	saEstablishedTs *prometheus.Desc
+	saRekeyTs       *prometheus.Desc
+	saExpireTs      *prometheus.Desc
 }
 
-func NewSasCollector(prefix string, viciClientFn viciClientFn) prometheus.Collector {
+func NewSasCollector(prefix string, viciClientFn viciClientFn, cache *SasCache, infoLabels []string, now func() time.Time, schema MetricsSchema) prometheus.Collector {
+	if infoLabels == nil {
+		infoLabels = DefaultIkeInfoLabels
+	}
+	var validLabels []string
+	for _, l := range ikeInfoLabels {
+		if slices.Contains(infoLabels, l) {
+			validLabels = append(validLabels, l)
+		}
+	}
+	for _, l := range infoLabels {
+		if !slices.Contains(ikeInfoLabels, l) {
+			log.Logger.Warnf("Unknown IKE info label: '%v'", l)
+		}
+	}
+
+	// v2 puts the same identifying labels on all metrics of an IKE or child sa, the traffic selectors
+	// and the DH group are only on the info metrics.
+	childLabels := []string{"ike_name", "ike_id", "child_name", "child_id"}
+	childTSLabels := schema.labels([]string{"ike_name", "ike_id", "child_name", "child_id", "local_ts", "remote_ts"}, childLabels)
+	ikeKeyLabels := schema.labels([]string{"ike_name", "ike_id", "algorithm", "dh_group"}, []string{"ike_name", "ike_id", "algorithm"})
+	childKeyLabels := schema.labels([]string{"ike_name", "ike_id", "child_name", "child_id", "algorithm", "dh_group"}, []string{"ike_name", "ike_id", "child_name", "child_id", "algorithm"})
+	saInfoLabels := []string{"ike_name", "ike_id", "child_name", "child_id", "reqid", "mode", "protocol", "esn", "spi_in", "spi_out"}
+	saInfoLabels = schema.labels(saInfoLabels, append(slices.Clone(saInfoLabels), "local_ts", "remote_ts", "dh_group"))
+
 	return &SasCollector{
 		viciClientFn: viciClientFn,
+		cache:        cache,
+		infoLabels:   validLabels,
+		now:          now,
+		schema:       schema,
 
 		ikeCnt: prometheus.NewDesc(
 			prefix+"ike_count",
@@ -69,6 +153,11 @@ func NewSasCollector(prefix string, viciClientFn viciClientFn) prometheus.Collec
 			"Status of this IKE",
 			[]string{"ike_name", "ike_id"}, nil,
 		),
+		ikeState: prometheus.NewDesc(
+			prefix+"ike_state",
+			"State of this IKE, 1 for the current state",
+			[]string{"ike_name", "ike_id", "state"}, nil,
+		),
 		ikeInitiator: prometheus.NewDesc(
 			prefix+"ike_initiator",
 			"Flag if the server is the initiator for this connection",
@@ -95,14 +184,14 @@ func NewSasCollector(prefix string, viciClientFn viciClientFn) prometheus.Collec
 			[]string{"ike_name", "ike_id"}, nil,
 		),
 		ikeEncKeySize: prometheus.NewDesc(
-			prefix+"ike_encryption_key_size",
+			prefix+schema.name("ike_encryption_key_size", "ike_encryption_key_bits"),
 			"Key size of the encryption algorithm",
-			[]string{"ike_name", "ike_id", "algorithm", "dh_group"}, nil,
+			ikeKeyLabels, nil,
 		),
 		ikeIntegKeySize: prometheus.NewDesc(
-			prefix+"ike_integrity_key_size",
+			prefix+schema.name("ike_integrity_key_size", "ike_integrity_key_bits"),
 			"Key size of the integrity algorithm",
-			[]string{"ike_name", "ike_id", "algorithm", "dh_group"}, nil,
+			ikeKeyLabels, nil,
 		),
 		ikeEstablishSecs: prometheus.NewDesc(
 			prefix+"ike_established_seconds",
@@ -120,15 +209,30 @@ func NewSasCollector(prefix string, viciClientFn viciClientFn) prometheus.Collec
 			[]string{"ike_name", "ike_id"}, nil,
 		),
 		ikeChildren: prometheus.NewDesc(
-			prefix+"ike_children_size",
+			prefix+schema.name("ike_children_size", "ike_child_sas"),
 			"Count of children of this IKE",
 			[]string{"ike_name", "ike_id"}, nil,
 		),
+		ikeInfo: prometheus.NewDesc(
+			prefix+"ike_info",
+			"Peers, identities and negotiated proposal of this IKE",
+			append([]string{"ike_name", "ike_id"}, validLabels...), nil,
+		),
 
 		saStatus: prometheus.NewDesc(
 			prefix+"sa_status",
 			"Status of this child sa",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "local_ts", "remote_ts"}, nil,
+			childTSLabels, nil,
+		),
+		saState: prometheus.NewDesc(
+			prefix+"sa_state",
+			"State of this child sa, 1 for the current state",
+			[]string{"ike_name", "ike_id", "child_name", "child_id", "state"}, nil,
+		),
+		saInfo: prometheus.NewDesc(
+			prefix+"sa_info",
+			"SPIs, mode, protocol, ESN and reqid of this child sa",
+			saInfoLabels, nil,
 		),
 		saEncap: prometheus.NewDesc(
 			prefix+"sa_encap",
@@ -136,44 +240,44 @@ func NewSasCollector(prefix string, viciClientFn viciClientFn) prometheus.Collec
 			[]string{"ike_name", "ike_id", "child_name", "child_id"}, nil,
 		),
 		saEncKeySize: prometheus.NewDesc(
-			prefix+"sa_encryption_key_size",
+			prefix+schema.name("sa_encryption_key_size", "sa_encryption_key_bits"),
 			"Key size of the encryption algorithm",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "algorithm", "dh_group"}, nil,
+			childKeyLabels, nil,
 		),
 		saIntegKeySize: prometheus.NewDesc(
-			prefix+"sa_integrity_key_size",
+			prefix+schema.name("sa_integrity_key_size", "sa_integrity_key_bits"),
 			"Key size of the integrity algorithm",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "algorithm", "dh_group"}, nil,
+			childKeyLabels, nil,
 		),
 		saBytesIn: prometheus.NewDesc(
-			prefix+"sa_inbound_bytes",
+			prefix+schema.name("sa_inbound_bytes", "sa_inbound_bytes_total"),
 			"Number of input bytes processed",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "local_ts", "remote_ts"}, nil,
+			childTSLabels, nil,
 		),
 		saPacketsIn: prometheus.NewDesc(
-			prefix+"sa_inbound_packets",
+			prefix+schema.name("sa_inbound_packets", "sa_inbound_packets_total"),
 			"Number of input packets processed",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "local_ts", "remote_ts"}, nil,
+			childTSLabels, nil,
 		),
 		saLastInSecs: prometheus.NewDesc(
 			prefix+"sa_last_inbound_seconds",
 			"Number of seconds since the last inbound packet was received",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "local_ts", "remote_ts"}, nil,
+			childTSLabels, nil,
 		),
 		saBytesOut: prometheus.NewDesc(
-			prefix+"sa_outbound_bytes",
+			prefix+schema.name("sa_outbound_bytes", "sa_outbound_bytes_total"),
 			"Number of output bytes processed",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "local_ts", "remote_ts"}, nil,
+			childTSLabels, nil,
 		),
 		saPacketsOut: prometheus.NewDesc(
-			prefix+"sa_outbound_packets",
+			prefix+schema.name("sa_outbound_packets", "sa_outbound_packets_total"),
 			"Number of output packets processed",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "local_ts", "remote_ts"}, nil,
+			childTSLabels, nil,
 		),
 		saLastOutSecs: prometheus.NewDesc(
 			prefix+"sa_last_outbound_seconds",
 			"Number of seconds since the last outbound packet was sent",
-			[]string{"ike_name", "ike_id", "child_name", "child_id", "local_ts", "remote_ts"}, nil,
+			childTSLabels, nil,
 		),
 		saEstablishSecs: prometheus.NewDesc(
 			prefix+"sa_established_seconds",
@@ -190,6 +294,47 @@ func NewSasCollector(prefix string, viciClientFn viciClientFn) prometheus.Collec
 			"Seconds until the lifetime expires",
 			[]string{"ike_name", "ike_id", "child_name", "child_id"}, nil,
 		),
+
+		ikeEstablishedTs: prometheus.NewDesc(
+			prefix+"ike_established_timestamp_seconds",
+			"Unix timestamp when the IKE was established",
+			[]string{"ike_name", "ike_id"}, nil,
+		),
+		ikeRekeyTs: prometheus.NewDesc(
+			prefix+"ike_next_rekey_timestamp_seconds",
+			"Unix timestamp when the IKE will be rekeyed",
+			[]string{"ike_name", "ike_id"}, nil,
+		),
+		ikeReauthTs: prometheus.NewDesc(
+			prefix+"ike_next_reauth_timestamp_seconds",
+			"Unix timestamp when the IKE will be reauthed",
+			[]string{"ike_name", "ike_id"}, nil,
+		),
+		saLastInTs: prometheus.NewDesc(
+			prefix+"sa_last_inbound_timestamp_seconds",
+			"Unix timestamp when the last inbound packet was received",
+			childTSLabels, nil,
+		),
+		saLastOutTs: prometheus.NewDesc(
+			prefix+"sa_last_outbound_timestamp_seconds",
+			"Unix timestamp when the last outbound packet was sent",
+			childTSLabels, nil,
+		),
+		saEstablishedTs: prometheus.NewDesc(
+			prefix+"sa_established_timestamp_seconds",
+			"Unix timestamp when the child SA was established",
+			[]string{"ike_name", "ike_id", "child_name", "child_id"}, nil,
+		),
+		saRekeyTs: prometheus.NewDesc(
+			prefix+"sa_next_rekey_timestamp_seconds",
+			"Unix timestamp when the child SA will be rekeyed",
+			[]string{"ike_name", "ike_id", "child_name", "child_id"}, nil,
+		),
+		saExpireTs: prometheus.NewDesc(
+			prefix+"sa_expire_timestamp_seconds",
+			"Unix timestamp when the lifetime of the child SA expires",
+			[]string{"ike_name", "ike_id", "child_name", "child_id"}, nil,
+		),
 	}
 }
 
@@ -197,6 +342,7 @@ func (c *SasCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.ikeCnt
 	ch <- c.ikeVersion
 	ch <- c.ikeStatus
+	ch <- c.ikeState
 	ch <- c.ikeInitiator
 	ch <- c.ikeNatLocal
 	ch <- c.ikeNatRemote
@@ -208,8 +354,11 @@ func (c *SasCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.ikeRekeySecs
 	ch <- c.ikeReauthSecs
 	ch <- c.ikeChildren
+	ch <- c.ikeInfo
 
 	ch <- c.saStatus
+	ch <- c.saState
+	ch <- c.saInfo
 	ch <- c.saEncap
 	ch <- c.saEncKeySize
 	ch <- c.saIntegKeySize
@@ -222,10 +371,22 @@ func (c *SasCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.saEstablishSecs
 	ch <- c.saRekeySecs
 	ch <- c.saLifetimeSecs
+	ch <- c.ikeEstablishedTs
+	ch <- c.ikeRekeyTs
+	ch <- c.ikeReauthTs
+	ch <- c.saLastInTs
+	ch <- c.saLastOutTs
+	ch <- c.saEstablishedTs
+	ch <- c.saRekeyTs
+	ch <- c.saExpireTs
 }
 
 func (c *SasCollector) Collect(ch chan<- prometheus.Metric) {
-	sas, err := c.listSas()
+	c.collect(ch, newScrape(c.viciClientFn, c.cache))
+}
+
+func (c *SasCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	sas, err := s.listSas()
 	if err != nil {
 		ch <- prometheus.MustNewConstMetric(
 			c.ikeCnt,
@@ -239,15 +400,16 @@ func (c *SasCollector) Collect(ch chan<- prometheus.Metric) {
 		prometheus.GaugeValue,
 		float64(len(sas)),
 	)
+	now := c.now()
 	for _, ikeSa := range sas {
-		c.collectIkeMetrics(ikeSa, ch)
+		c.collectIkeMetrics(ikeSa, now, ch)
 		for _, child := range ikeSa.Children {
-			c.collectIkeChildMetrics(ikeSa.Name, ikeSa.UniqueID, child, ch)
+			c.collectIkeChildMetrics(ikeSa.Name, ikeSa.UniqueID, child, now, ch)
 		}
 	}
 }
 
-func (c *SasCollector) collectIkeMetrics(ikeSa IkeSa, ch chan<- prometheus.Metric) {
+func (c *SasCollector) collectIkeMetrics(ikeSa IkeSa, now time.Time, ch chan<- prometheus.Metric) {
 	ch <- prometheus.MustNewConstMetric(
 		c.ikeVersion,
 		prometheus.GaugeValue,
@@ -261,6 +423,14 @@ func (c *SasCollector) collectIkeMetrics(ikeSa IkeSa, ch chan<- prometheus.Metri
 		float64(viciStateToInt(ikeSa.State)),
 		ikeSa.Name, ikeSa.UniqueID,
 	)
+	for _, state := range ikeStates {
+		ch <- prometheus.MustNewConstMetric(
+			c.ikeState,
+			prometheus.GaugeValue,
+			boolToFloat(ikeSa.State == state),
+			ikeSa.Name, ikeSa.UniqueID, state,
+		)
+	}
 
 	ch <- prometheus.MustNewConstMetric(
 		c.ikeInitiator,
@@ -296,13 +466,13 @@ func (c *SasCollector) collectIkeMetrics(ikeSa IkeSa, ch chan<- prometheus.Metri
 		c.ikeEncKeySize,
 		prometheus.GaugeValue,
 		float64(ikeSa.EncKey),
-		ikeSa.Name, ikeSa.UniqueID, ikeSa.EncAlg, ikeSa.DHGroup,
+		c.keyLabels([]string{ikeSa.Name, ikeSa.UniqueID}, ikeSa.EncAlg, ikeSa.DHGroup)...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.ikeIntegKeySize,
 		prometheus.GaugeValue,
 		float64(ikeSa.IntegKey),
-		ikeSa.Name, ikeSa.UniqueID, ikeSa.IntegAlg, ikeSa.DHGroup,
+		c.keyLabels([]string{ikeSa.Name, ikeSa.UniqueID}, ikeSa.IntegAlg, ikeSa.DHGroup)...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.ikeEstablishSecs,
@@ -322,22 +492,86 @@ func (c *SasCollector) collectIkeMetrics(ikeSa IkeSa, ch chan<- prometheus.Metri
 		float64(ikeSa.ReauthSec),
 		ikeSa.Name, ikeSa.UniqueID,
 	)
+	// The established time is only listed once the IKE SA was established, e.g. not while it is connecting.
+	if ikeSa.State == "ESTABLISHED" || ikeSa.EstablishSec > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.ikeEstablishedTs,
+			prometheus.GaugeValue,
+			relativeTimestamp(now, -ikeSa.EstablishSec),
+			ikeSa.Name, ikeSa.UniqueID,
+		)
+	}
+	// A rekey or reauth time of 0 means it is disabled.
+	if ikeSa.RekeySec > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.ikeRekeyTs,
+			prometheus.GaugeValue,
+			relativeTimestamp(now, ikeSa.RekeySec),
+			ikeSa.Name, ikeSa.UniqueID,
+		)
+	}
+	if ikeSa.ReauthSec > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.ikeReauthTs,
+			prometheus.GaugeValue,
+			relativeTimestamp(now, ikeSa.ReauthSec),
+			ikeSa.Name, ikeSa.UniqueID,
+		)
+	}
 	ch <- prometheus.MustNewConstMetric(
 		c.ikeChildren,
 		prometheus.GaugeValue,
 		float64(len(ikeSa.Children)),
 		ikeSa.Name, ikeSa.UniqueID,
 	)
+
+	infoLabels := make([]string, 0, 2+len(c.infoLabels))
+	infoLabels = append(infoLabels, ikeSa.Name, ikeSa.UniqueID)
+	for _, l := range c.infoLabels {
+		infoLabels = append(infoLabels, ikeInfoLabelValues[l](ikeSa))
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.ikeInfo,
+		prometheus.GaugeValue,
+		1,
+		infoLabels...,
+	)
 }
 
-func (c *SasCollector) collectIkeChildMetrics(name string, uniqueID string, childIkeSa ChildIkeSa, ch chan<- prometheus.Metric) {
+func (c *SasCollector) collectIkeChildMetrics(name string, uniqueID string, childIkeSa ChildIkeSa, now time.Time, ch chan<- prometheus.Metric) {
 	localTSs := strings.Join(childIkeSa.LocalTS, ";")
 	remoteTSs := strings.Join(childIkeSa.RemoteTS, ";")
+	ids := []string{name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID}
+	tsLabels := ids
+	if !c.schema.v2() {
+		tsLabels = []string{name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, localTSs, remoteTSs}
+	}
 	ch <- prometheus.MustNewConstMetric(
 		c.saStatus,
 		prometheus.GaugeValue,
 		float64(viciStateToInt(childIkeSa.State)),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, localTSs, remoteTSs,
+		tsLabels...,
+	)
+	for _, state := range childStates {
+		ch <- prometheus.MustNewConstMetric(
+			c.saState,
+			prometheus.GaugeValue,
+			boolToFloat(childIkeSa.State == state),
+			name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, state,
+		)
+	}
+	infoLabels := []string{
+		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, childIkeSa.ReqID, childIkeSa.Mode, childIkeSa.Protocol,
+		strconv.Itoa(esnToInt(childIkeSa.Esn)), childIkeSa.SpiIn, childIkeSa.SpiOut,
+	}
+	if c.schema.v2() {
+		infoLabels = append(infoLabels, localTSs, remoteTSs, childIkeSa.DHGroup)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.saInfo,
+		prometheus.GaugeValue,
+		1,
+		infoLabels...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saEncap,
@@ -349,49 +583,49 @@ func (c *SasCollector) collectIkeChildMetrics(name string, uniqueID string, chil
 		c.saEncKeySize,
 		prometheus.GaugeValue,
 		float64(childIkeSa.EncKey),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, childIkeSa.EncAlg, childIkeSa.DHGroup,
+		c.keyLabels(ids, childIkeSa.EncAlg, childIkeSa.DHGroup)...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saIntegKeySize,
 		prometheus.GaugeValue,
 		float64(childIkeSa.IntegKey),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, childIkeSa.IntegAlg, childIkeSa.DHGroup,
+		c.keyLabels(ids, childIkeSa.IntegAlg, childIkeSa.DHGroup)...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saBytesIn,
-		prometheus.GaugeValue,
+		c.schema.counterType(),
 		float64(childIkeSa.BytesIn),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, localTSs, remoteTSs,
+		tsLabels...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saPacketsIn,
-		prometheus.GaugeValue,
+		c.schema.counterType(),
 		float64(childIkeSa.PacketsIn),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, localTSs, remoteTSs,
+		tsLabels...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saLastInSecs,
 		prometheus.GaugeValue,
 		float64(childIkeSa.LastInSec),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, localTSs, remoteTSs,
+		tsLabels...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saBytesOut,
-		prometheus.GaugeValue,
+		c.schema.counterType(),
 		float64(childIkeSa.BytesOut),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, localTSs, remoteTSs,
+		tsLabels...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saPacketsOut,
-		prometheus.GaugeValue,
+		c.schema.counterType(),
 		float64(childIkeSa.PacketsOut),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, localTSs, remoteTSs,
+		tsLabels...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saLastOutSecs,
 		prometheus.GaugeValue,
 		float64(childIkeSa.LastOutSec),
-		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID, localTSs, remoteTSs,
+		tsLabels...,
 	)
 	ch <- prometheus.MustNewConstMetric(
 		c.saEstablishSecs,
@@ -411,10 +645,64 @@ func (c *SasCollector) collectIkeChildMetrics(name string, uniqueID string, chil
 		float64(childIkeSa.LifetimeSec),
 		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID,
 	)
+
+	// Vici reports the last use only once a packet was processed.
+	if childIkeSa.PacketsIn > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.saLastInTs,
+			prometheus.GaugeValue,
+			relativeTimestamp(now, -childIkeSa.LastInSec),
+			tsLabels...,
+		)
+	}
+	if childIkeSa.PacketsOut > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.saLastOutTs,
+			prometheus.GaugeValue,
+			relativeTimestamp(now, -childIkeSa.LastOutSec),
+			tsLabels...,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.saEstablishedTs,
+		prometheus.GaugeValue,
+		relativeTimestamp(now, -childIkeSa.EstablishSec),
+		name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID,
+	)
+	if childIkeSa.RekeySec > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.saRekeyTs,
+			prometheus.GaugeValue,
+			relativeTimestamp(now, childIkeSa.RekeySec),
+			name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID,
+		)
+	}
+	if childIkeSa.LifetimeSec > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.saExpireTs,
+			prometheus.GaugeValue,
+			relativeTimestamp(now, childIkeSa.LifetimeSec),
+			name, uniqueID, childIkeSa.Name, childIkeSa.UniqueID,
+		)
+	}
 }
 
-func (c *SasCollector) listSas() ([]IkeSa, error) {
-	s, err := c.viciClientFn()
+// relativeTimestamp returns the Unix timestamp of now shifted by the given seconds as reported by Vici.
+func relativeTimestamp(now time.Time, secs int64) float64 {
+	return float64(now.Add(time.Duration(secs) * time.Second).Unix())
+}
+
+// keyLabels returns the label values of the key size metrics, v2 has no DH group label.
+func (c *SasCollector) keyLabels(ids []string, alg string, dhGroup string) []string {
+	labels := append(slices.Clone(ids), alg)
+	if !c.schema.v2() {
+		labels = append(labels, dhGroup)
+	}
+	return labels
+}
+
+func listSas(viciClientFn viciClientFn) ([]IkeSa, error) {
+	s, err := viciClientFn()
 	if err != nil {
 		return nil, err
 	}
@@ -431,20 +719,30 @@ func (c *SasCollector) listSas() ([]IkeSa, error) {
 			log.Logger.Warnf("Message error: %v", e)
 			continue
 		}
-		for _, k := range m.Keys() {
-			rawMsg := m.Get(k).(*vici.Message)
-			var ikeSa IkeSa
-			if e := vici.UnmarshalMessage(rawMsg, &ikeSa); e != nil {
-				log.Logger.Warnf("Message unmarshal error: %v", e)
-				continue
-			}
-			ikeSa.Name = k
-			res = append(res, ikeSa)
-		}
+		res = append(res, unmarshalIkeSas(m)...)
 	}
 	return res, nil
 }
 
+// unmarshalIkeSas returns the IKE SAs of a list-sa or event message, which are the sections keyed by the IKE name.
+func unmarshalIkeSas(m *vici.Message) []IkeSa {
+	var res []IkeSa
+	for _, k := range m.Keys() {
+		rawMsg, ok := m.Get(k).(*vici.Message)
+		if !ok {
+			continue
+		}
+		var ikeSa IkeSa
+		if e := vici.UnmarshalMessage(rawMsg, &ikeSa); e != nil {
+			log.Logger.Warnf("Message unmarshal error: %v", e)
+			continue
+		}
+		ikeSa.Name = k
+		res = append(res, ikeSa)
+	}
+	return res
+}
+
 func viciBoolToInt(v string) int {
 	if v == "yes" {
 		return 1
@@ -452,6 +750,21 @@ func viciBoolToInt(v string) int {
 	return 0
 }
 
+// esnToInt parses the esn flag of list-sas, which charon sends as "1" instead of "yes".
+func esnToInt(v string) int {
+	if v == "1" {
+		return 1
+	}
+	return viciBoolToInt(v)
+}
+
+func boolToFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
 func viciStateToInt(v string) connectionStatus {
 	switch v {
 	case "ESTABLISHED":
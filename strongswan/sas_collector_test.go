@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
@@ -16,9 +17,19 @@ type fakeViciClient struct {
 	saMsgs         []*vici.Message
 	certMsgs       []*vici.Message
 	connMsgs       []*vici.Message
+	policyMsgs     []*vici.Message
+	authorityMsgs  []*vici.Message
+	cmdMsgs        map[string]*vici.Message
 	closeTriggered int
 }
 
+func (fvc *fakeViciClient) CommandRequest(cmd string, _ *vici.Message) (*vici.Message, error) {
+	if m, ok := fvc.cmdMsgs[cmd]; ok {
+		return m, fvc.err
+	}
+	return nil, errors.New("invalid command")
+}
+
 func (fvc *fakeViciClient) StreamedCommandRequest(cmd string, event string, _ *vici.Message) ([]*vici.Message, error) {
 	if cmd == "list-sas" && event == "list-sa" {
 		return fvc.saMsgs, fvc.err
@@ -29,6 +40,12 @@ func (fvc *fakeViciClient) StreamedCommandRequest(cmd string, event string, _ *v
 	if cmd == "list-conns" && event == "list-conn" {
 		return fvc.connMsgs, fvc.err
 	}
+	if cmd == "list-policies" && event == "list-policy" {
+		return fvc.policyMsgs, fvc.err
+	}
+	if cmd == "list-authorities" && event == "list-authority" {
+		return fvc.authorityMsgs, fvc.err
+	}
 	return nil, errors.New("invalid command")
 }
 
@@ -88,7 +105,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsHelp:  "Number of known IKEs",
 			wantMetricsType:  "gauge",
 			wantMetricsValue: 1,
-			wantMetricsCount: 14,
+			wantMetricsCount: 23,
 		},
 		{
 			name: "two ike count",
@@ -100,7 +117,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsHelp:  "Number of known IKEs",
 			wantMetricsType:  "gauge",
 			wantMetricsValue: 2,
-			wantMetricsCount: 27,
+			wantMetricsCount: 45,
 		},
 		{
 			name: "ike version & name & uniqueid",
@@ -115,7 +132,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  5,
-			wantMetricsCount:  14,
+			wantMetricsCount:  23,
 		},
 		{
 			name: "ike status",
@@ -130,7 +147,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1,
-			wantMetricsCount:  14,
+			wantMetricsCount:  24,
 		},
 		{
 			name: "ike initiator",
@@ -145,7 +162,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1,
-			wantMetricsCount:  14,
+			wantMetricsCount:  23,
 		},
 		{
 			name: "ike NAT local",
@@ -160,7 +177,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1,
-			wantMetricsCount:  14,
+			wantMetricsCount:  23,
 		},
 		{
 			name: "ike NAT remote",
@@ -175,7 +192,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1,
-			wantMetricsCount:  14,
+			wantMetricsCount:  23,
 		},
 		{
 			name: "ike NAT fake",
@@ -190,7 +207,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1,
-			wantMetricsCount:  14,
+			wantMetricsCount:  23,
 		},
 		{
 			name: "ike NAT any",
@@ -205,7 +222,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1,
-			wantMetricsCount:  14,
+			wantMetricsCount:  23,
 		},
 		{
 			name: "ike encryption key",
@@ -222,7 +239,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `algorithm="SHA-256",dh_group="DH",ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1024,
-			wantMetricsCount:  14,
+			wantMetricsCount:  23,
 		},
 		{
 			name: "ike integrity key",
@@ -239,7 +256,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `algorithm="SHA-256",dh_group="DH",ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1024,
-			wantMetricsCount:  14,
+			wantMetricsCount:  23,
 		},
 		{
 			name: "ike established",
@@ -254,7 +271,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  565,
-			wantMetricsCount:  14,
+			wantMetricsCount:  24,
 		},
 		{
 			name: "ike rekey",
@@ -269,7 +286,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  12,
-			wantMetricsCount:  14,
+			wantMetricsCount:  24,
 		},
 		{
 			name: "ike reauth",
@@ -284,7 +301,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  15,
-			wantMetricsCount:  14,
+			wantMetricsCount:  24,
 		},
 		{
 			name: "ike children",
@@ -306,7 +323,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  2,
-			wantMetricsCount:  40,
+			wantMetricsCount:  75,
 		},
 	}
 	for _, tt := range tests {
@@ -317,7 +334,7 @@ func TestSasCollector_Metrics(t *testing.T) {
 			}
 			c := NewSasCollector("swtest_", func() (ViciClient, error) {
 				return &fakeViciClient{saMsgs: []*vici.Message{msgs}, err: tt.viciSessionErr}, tt.viciClientErr
-			})
+			}, nil, nil, time.Now, MetricsSchemaV1)
 
 			cnt := testutil.CollectAndCount(c)
 			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
@@ -342,6 +359,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 		wantMetricsType   string
 		wantMetricsLabels string
 		wantMetricsValue  int
+		wantMetricsCount  int
 	}{
 		{
 			name: "sa status",
@@ -355,6 +373,24 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",local_ts="local-ts-1;local-ts-2",remote_ts="remote-ts-1;remote-ts-2"`,
 			wantMetricsValue:  0,
+			wantMetricsCount:  49,
+		},
+		{
+			name: "sa info",
+			msgModifierFn: func(msg *vici.Message) {
+				msg.Set("reqid", 7)
+				msg.Set("mode", "TUNNEL")
+				msg.Set("protocol", "ESP")
+				msg.Set("esn", "1")
+				msg.Set("spi-in", "c2a3b1f0")
+				msg.Set("spi-out", "cd1f22e4")
+			},
+			metricName:        "swtest_sa_info",
+			wantMetricsHelp:   "SPIs, mode, protocol, ESN and reqid of this child sa",
+			wantMetricsType:   "gauge",
+			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",esn="1",ike_id="some-unique-id",ike_name="ike-name",mode="TUNNEL",protocol="ESP",reqid="7",spi_in="c2a3b1f0",spi_out="cd1f22e4"`,
+			wantMetricsValue:  1,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa encapsulation",
@@ -366,6 +402,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa encryption key",
@@ -379,6 +416,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `algorithm="SHA-256",child_id="sa-unique-id",child_name="sa-name",dh_group="DH",ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1024,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa integrity key",
@@ -392,6 +430,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `algorithm="SHA-256",child_id="sa-unique-id",child_name="sa-name",dh_group="DH",ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  1024,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa bytes in",
@@ -405,6 +444,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",local_ts="local-ts-1;local-ts-2",remote_ts="remote-ts-1;remote-ts-2"`,
 			wantMetricsValue:  125,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa packets in",
@@ -418,6 +458,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",local_ts="local-ts-1;local-ts-2",remote_ts="remote-ts-1;remote-ts-2"`,
 			wantMetricsValue:  125,
+			wantMetricsCount:  50,
 		},
 		{
 			name: "sa last in seconds",
@@ -431,6 +472,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",local_ts="local-ts-1;local-ts-2",remote_ts="remote-ts-1;remote-ts-2"`,
 			wantMetricsValue:  60,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa bytes out",
@@ -444,6 +486,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",local_ts="local-ts-1;local-ts-2",remote_ts="remote-ts-1;remote-ts-2"`,
 			wantMetricsValue:  125,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa packets out",
@@ -457,6 +500,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",local_ts="local-ts-1;local-ts-2",remote_ts="remote-ts-1;remote-ts-2"`,
 			wantMetricsValue:  125,
+			wantMetricsCount:  50,
 		},
 		{
 			name: "sa last out seconds",
@@ -470,6 +514,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",local_ts="local-ts-1;local-ts-2",remote_ts="remote-ts-1;remote-ts-2"`,
 			wantMetricsValue:  60,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa last established seconds",
@@ -481,6 +526,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  32,
+			wantMetricsCount:  49,
 		},
 		{
 			name: "sa last rekey seconds",
@@ -492,6 +538,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  33,
+			wantMetricsCount:  50,
 		},
 		{
 			name: "sa lifetime seconds",
@@ -503,6 +550,7 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			wantMetricsType:   "gauge",
 			wantMetricsLabels: `child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name"`,
 			wantMetricsValue:  34,
+			wantMetricsCount:  50,
 		},
 	}
 	for _, tt := range tests {
@@ -520,10 +568,10 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 			msgs.Set("ike-name", ikeMsg)
 			c := NewSasCollector("swtest_", func() (ViciClient, error) {
 				return &fakeViciClient{saMsgs: []*vici.Message{msgs}}, nil
-			})
+			}, nil, nil, time.Now, MetricsSchemaV1)
 
 			cnt := testutil.CollectAndCount(c)
-			require.Equal(t, 27, cnt, "metrics count")
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
 
 			wantMetricsContent := fmt.Sprintf(`# HELP %s %s
 # TYPE %s %s
@@ -535,3 +583,144 @@ func TestSasCollector_MetricsChild(t *testing.T) {
 		})
 	}
 }
+
+func TestSasCollector_StateSet(t *testing.T) {
+	msgs := vici.NewMessage()
+	msgs.Set("ike-name", ikeSaMsg("some-unique-id", "REKEYING", map[string]*vici.Message{
+		"sa-name-1": childSaMsg("sa-name", "sa-unique-id", "RETRYING"),
+	}))
+	c := NewSasCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{saMsgs: []*vici.Message{msgs}}, nil
+	}, nil, nil, time.Now, MetricsSchemaV1)
+
+	want := `# HELP swtest_ike_state State of this IKE, 1 for the current state
+# TYPE swtest_ike_state gauge
+swtest_ike_state{ike_id="some-unique-id",ike_name="ike-name",state="CONNECTING"} 0
+swtest_ike_state{ike_id="some-unique-id",ike_name="ike-name",state="CREATED"} 0
+swtest_ike_state{ike_id="some-unique-id",ike_name="ike-name",state="DELETING"} 0
+swtest_ike_state{ike_id="some-unique-id",ike_name="ike-name",state="DESTROYING"} 0
+swtest_ike_state{ike_id="some-unique-id",ike_name="ike-name",state="ESTABLISHED"} 0
+swtest_ike_state{ike_id="some-unique-id",ike_name="ike-name",state="PASSIVE"} 0
+swtest_ike_state{ike_id="some-unique-id",ike_name="ike-name",state="REKEYED"} 0
+swtest_ike_state{ike_id="some-unique-id",ike_name="ike-name",state="REKEYING"} 1
+# HELP swtest_sa_state State of this child sa, 1 for the current state
+# TYPE swtest_sa_state gauge
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="CREATED"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="DELETED"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="DELETING"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="DESTROYING"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="INSTALLED"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="INSTALLING"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="REKEYED"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="REKEYING"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="RETRYING"} 1
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="ROUTED"} 0
+swtest_sa_state{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",state="UPDATING"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "swtest_ike_state", "swtest_sa_state"); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestSasCollector_IkeInfo(t *testing.T) {
+	ikeMsg := vici.NewMessage()
+	ikeMsg.Set("uniqueid", "some-unique-id")
+	ikeMsg.Set("local-host", "10.0.0.1")
+	ikeMsg.Set("local-port", 4500)
+	ikeMsg.Set("local-id", "gw.example.com")
+	ikeMsg.Set("remote-host", "192.0.2.10")
+	ikeMsg.Set("remote-port", 4500)
+	ikeMsg.Set("remote-id", "carol@example.com")
+	ikeMsg.Set("initiator-spi", "5a9e1b3c8d7f6e21")
+	ikeMsg.Set("responder-spi", "c0ffee0123456789")
+	ikeMsg.Set("encr-alg", "AES_CBC")
+	ikeMsg.Set("encr-keysize", 256)
+	ikeMsg.Set("integ-alg", "HMAC_SHA2_256_128")
+	ikeMsg.Set("prf-alg", "PRF_HMAC_SHA2_256")
+	ikeMsg.Set("dh-group", "CURVE_25519")
+	msgs := vici.NewMessage()
+	msgs.Set("ike-name", ikeMsg)
+
+	tests := []struct {
+		name       string
+		infoLabels []string
+		wantLabels string
+	}{
+		{
+			name:       "default labels",
+			infoLabels: nil,
+			wantLabels: `dh_group="CURVE_25519",encr_alg="AES_CBC",encr_keysize="256",ike_id="some-unique-id",ike_name="ike-name",integ_alg="HMAC_SHA2_256_128",integ_keysize="0",local_host="10.0.0.1",local_id="gw.example.com",local_port="4500",prf_alg="PRF_HMAC_SHA2_256",remote_host="192.0.2.10",remote_id="carol@example.com",remote_port="4500"`,
+		},
+		{
+			name:       "selected labels",
+			infoLabels: []string{"remote_id", "initiator_spi", "responder_spi", "unknown"},
+			wantLabels: `ike_id="some-unique-id",ike_name="ike-name",initiator_spi="5a9e1b3c8d7f6e21",remote_id="carol@example.com",responder_spi="c0ffee0123456789"`,
+		},
+		{
+			name:       "no optional labels",
+			infoLabels: []string{},
+			wantLabels: `ike_id="some-unique-id",ike_name="ike-name"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSasCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{saMsgs: []*vici.Message{msgs}}, nil
+			}, nil, tt.infoLabels, time.Now, MetricsSchemaV1)
+
+			want := fmt.Sprintf(`# HELP swtest_ike_info Peers, identities and negotiated proposal of this IKE
+# TYPE swtest_ike_info gauge
+swtest_ike_info{%s} 1
+`, tt.wantLabels)
+			if err := testutil.CollectAndCompare(c, strings.NewReader(want), "swtest_ike_info"); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
+
+func TestSasCollector_Timestamps(t *testing.T) {
+	child := childSaMsg("sa-name", "sa-unique-id", "INSTALLED")
+	child.Set("rekey-time", 30)
+	child.Set("packets-in", 5)
+	child.Set("use-in", 2)
+	ike := ikeSaMsg("some-unique-id", "ESTABLISHED", map[string]*vici.Message{"sa-name-1": child})
+	ike.Set("rekey-time", 3600)
+	connecting := ikeSaMsg("other-unique-id", "CONNECTING", nil)
+	connecting.Unset("established")
+	msgs := vici.NewMessage()
+	msgs.Set("ike-name", ike)
+	msgs.Set("other-ike-name", connecting)
+	c := NewSasCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{saMsgs: []*vici.Message{msgs}}, nil
+	}, nil, nil, func() time.Time {
+		return time.Unix(1000, 0)
+	}, MetricsSchemaV1)
+
+	want := `# HELP swtest_ike_established_timestamp_seconds Unix timestamp when the IKE was established
+# TYPE swtest_ike_established_timestamp_seconds gauge
+swtest_ike_established_timestamp_seconds{ike_id="some-unique-id",ike_name="ike-name"} 990
+# HELP swtest_ike_next_rekey_timestamp_seconds Unix timestamp when the IKE will be rekeyed
+# TYPE swtest_ike_next_rekey_timestamp_seconds gauge
+swtest_ike_next_rekey_timestamp_seconds{ike_id="some-unique-id",ike_name="ike-name"} 4600
+# HELP swtest_sa_established_timestamp_seconds Unix timestamp when the child SA was established
+# TYPE swtest_sa_established_timestamp_seconds gauge
+swtest_sa_established_timestamp_seconds{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name"} 995
+# HELP swtest_sa_expire_timestamp_seconds Unix timestamp when the lifetime of the child SA expires
+# TYPE swtest_sa_expire_timestamp_seconds gauge
+swtest_sa_expire_timestamp_seconds{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name"} 1100
+# HELP swtest_sa_last_inbound_timestamp_seconds Unix timestamp when the last inbound packet was received
+# TYPE swtest_sa_last_inbound_timestamp_seconds gauge
+swtest_sa_last_inbound_timestamp_seconds{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name",local_ts="",remote_ts=""} 998
+# HELP swtest_sa_next_rekey_timestamp_seconds Unix timestamp when the child SA will be rekeyed
+# TYPE swtest_sa_next_rekey_timestamp_seconds gauge
+swtest_sa_next_rekey_timestamp_seconds{child_id="sa-unique-id",child_name="sa-name",ike_id="some-unique-id",ike_name="ike-name"} 1030
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"swtest_ike_established_timestamp_seconds", "swtest_ike_next_rekey_timestamp_seconds", "swtest_ike_next_reauth_timestamp_seconds",
+		"swtest_sa_established_timestamp_seconds", "swtest_sa_expire_timestamp_seconds", "swtest_sa_next_rekey_timestamp_seconds",
+		"swtest_sa_last_inbound_timestamp_seconds", "swtest_sa_last_outbound_timestamp_seconds")
+	if err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
@@ -0,0 +1,58 @@
+package strongswan
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+MetricsSchema selects the names, types and labels of the exported metrics. v1 is the original schema and
+the default, v2 follows the Prometheus naming conventions: counters for byte and packet values, unit
+suffixes, absolute times as _timestamp_seconds and the same identifying labels on all metrics of an object.
+*/
+type MetricsSchema string
+
+const (
+	MetricsSchemaV1 MetricsSchema = "v1"
+	MetricsSchemaV2 MetricsSchema = "v2"
+)
+
+func ParseMetricsSchema(v string) (MetricsSchema, error) {
+	switch MetricsSchema(v) {
+	case MetricsSchemaV1, "":
+		return MetricsSchemaV1, nil
+	case MetricsSchemaV2:
+		return MetricsSchemaV2, nil
+	default:
+		return "", fmt.Errorf("unknown metrics schema: '%v'", v)
+	}
+}
+
+func (s MetricsSchema) v2() bool {
+	return s == MetricsSchemaV2
+}
+
+// name returns the metric name of the schema.
+func (s MetricsSchema) name(v1 string, v2 string) string {
+	if s.v2() {
+		return v2
+	}
+	return v1
+}
+
+// labels returns the label names of the schema.
+func (s MetricsSchema) labels(v1 []string, v2 []string) []string {
+	if s.v2() {
+		return v2
+	}
+	return v1
+}
+
+// counterType returns the type of values which are exported as gauges in v1 although they only increase.
+func (s MetricsSchema) counterType() prometheus.ValueType {
+	if s.v2() {
+		return prometheus.CounterValue
+	}
+	return prometheus.GaugeValue
+}
@@ -0,0 +1,155 @@
+package strongswan
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func TestParseMetricsSchema(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    MetricsSchema
+		wantErr bool
+	}{
+		{value: "", want: MetricsSchemaV1},
+		{value: "v1", want: MetricsSchemaV1},
+		{value: "v2", want: MetricsSchemaV2},
+		{value: "v3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseMetricsSchema(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSasCollector_MetricsSchemaV2(t *testing.T) {
+	child := childSaMsg("net", "2", "INSTALLED")
+	child.Set("encr-alg", "AES_GCM_16")
+	child.Set("encr-keysize", 256)
+	child.Set("dh-group", "CURVE_25519")
+	child.Set("bytes-in", 1024)
+	child.Set("local-ts", []string{"10.1.0.0/16"})
+	child.Set("remote-ts", []string{"10.2.0.0/16"})
+	ike := ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{"net-2": child})
+	ike.Set("encr-alg", "AES_CBC")
+	ike.Set("encr-keysize", 128)
+	ike.Set("dh-group", "CURVE_25519")
+	msgs := vici.NewMessage()
+	msgs.Set("home", ike)
+
+	c := NewSasCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{saMsgs: []*vici.Message{msgs}}, nil
+	}, nil, []string{}, time.Now, MetricsSchemaV2)
+
+	want := `# HELP swtest_ike_child_sas Count of children of this IKE
+# TYPE swtest_ike_child_sas gauge
+swtest_ike_child_sas{ike_id="1",ike_name="home"} 1
+# HELP swtest_ike_encryption_key_bits Key size of the encryption algorithm
+# TYPE swtest_ike_encryption_key_bits gauge
+swtest_ike_encryption_key_bits{algorithm="AES_CBC",ike_id="1",ike_name="home"} 128
+# HELP swtest_sa_encryption_key_bits Key size of the encryption algorithm
+# TYPE swtest_sa_encryption_key_bits gauge
+swtest_sa_encryption_key_bits{algorithm="AES_GCM_16",child_id="2",child_name="net",ike_id="1",ike_name="home"} 256
+# HELP swtest_sa_inbound_bytes_total Number of input bytes processed
+# TYPE swtest_sa_inbound_bytes_total counter
+swtest_sa_inbound_bytes_total{child_id="2",child_name="net",ike_id="1",ike_name="home"} 1024
+# HELP swtest_sa_info SPIs, mode, protocol, ESN and reqid of this child sa
+# TYPE swtest_sa_info gauge
+swtest_sa_info{child_id="2",child_name="net",dh_group="CURVE_25519",esn="0",ike_id="1",ike_name="home",local_ts="10.1.0.0/16",mode="",protocol="",remote_ts="10.2.0.0/16",reqid="",spi_in="",spi_out=""} 1
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"swtest_ike_child_sas", "swtest_ike_encryption_key_bits", "swtest_sa_encryption_key_bits", "swtest_sa_inbound_bytes_total", "swtest_sa_info")
+	if err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestConnsCollector_MetricsSchemaV2(t *testing.T) {
+	conn := connMsg("net")
+	conn.Set("rekey_time", "14400")
+	conn.Get("children").(*vici.Message).Get("net").(*vici.Message).Set("rekey_bytes", "1000000")
+	msg := vici.NewMessage()
+	msg.Set("home", conn)
+
+	c := NewConnsCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{connMsgs: []*vici.Message{msg}}, nil
+	}, MetricsSchemaV2)
+
+	want := `# HELP swtest_conn_child_rekey_interval_bytes CHILD_SA rekeying interval in bytes
+# TYPE swtest_conn_child_rekey_interval_bytes gauge
+swtest_conn_child_rekey_interval_bytes{child_name="net",ike_name="home",mode="TUNNEL"} 1e+06
+# HELP swtest_conn_rekey_interval_seconds IKE_SA rekeying interval in seconds
+# TYPE swtest_conn_rekey_interval_seconds gauge
+swtest_conn_rekey_interval_seconds{ike_name="home"} 14400
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"swtest_conn_child_rekey_interval_bytes", "swtest_conn_rekey_interval_seconds")
+	if err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestConnStatusCollector_MetricsSchemaV2(t *testing.T) {
+	conns := vici.NewMessage()
+	conns.Set("home", connMsg("net"))
+	sas := vici.NewMessage()
+	sas.Set("home", ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{"net-2": childSaMsg("net", "2", "INSTALLED")}))
+
+	c := NewConnStatusCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{connMsgs: []*vici.Message{conns}, saMsgs: []*vici.Message{sas}}, nil
+	}, nil, MetricsSchemaV2)
+
+	// the connection name has the label of the SA metrics
+	want := `# HELP swtest_conn_child_up Flag if the CHILD_SA configuration has an installed CHILD SA
+# TYPE swtest_conn_child_up gauge
+swtest_conn_child_up{child_name="net",ike_name="home"} 1
+# HELP swtest_conn_up Flag if the connection has an established IKE SA
+# TYPE swtest_conn_up gauge
+swtest_conn_up{ike_name="home"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestCertsCollector_MetricsSchemaV2(t *testing.T) {
+	msg := vici.NewMessage()
+	msg.Set("type", "X509")
+	msg.Set("flags", "CA")
+	msg.Set("data", loadCert("testdata/cert-ca.pem"))
+
+	c := NewCertsCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{certMsgs: []*vici.Message{msg}}, nil
+	}, time.Local, func() time.Time {
+		return time.Unix(2026454400, 0) // 2034-03-20T08:00:00Z
+	}, MetricsSchemaV2, nil)
+
+	want := `# HELP swtest_cert_expire_seconds Seconds until the X509 certificate expires
+# TYPE swtest_cert_expire_seconds gauge
+swtest_cert_expire_seconds{serial_number="63:68:4d:00:11:20:7d:dc",subject="CN=Cyber Root CA,O=Cyber,C=CH"} 25264
+# HELP swtest_cert_not_after_timestamp_seconds End of the X509 certificate validity as Unix timestamp
+# TYPE swtest_cert_not_after_timestamp_seconds gauge
+swtest_cert_not_after_timestamp_seconds{serial_number="63:68:4d:00:11:20:7d:dc",subject="CN=Cyber Root CA,O=Cyber,C=CH"} 2.026479664e+09
+# HELP swtest_cert_not_before_timestamp_seconds Start of the X509 certificate validity as Unix timestamp
+# TYPE swtest_cert_not_before_timestamp_seconds gauge
+swtest_cert_not_before_timestamp_seconds{serial_number="63:68:4d:00:11:20:7d:dc",subject="CN=Cyber Root CA,O=Cyber,C=CH"} 1.710946864e+09
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"swtest_cert_expire_seconds", "swtest_cert_not_after_timestamp_seconds", "swtest_cert_not_before_timestamp_seconds")
+	if err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+	require.Equal(t, 6, testutil.CollectAndCount(c), "metrics count")
+}
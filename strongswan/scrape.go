@@ -0,0 +1,79 @@
+package strongswan
+
+import (
+	"crypto/x509"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeCollector is a collector that reads the listings shared by the collectors of a scrape.
+type scrapeCollector interface {
+	collect(ch chan<- prometheus.Metric, s *scrape)
+}
+
+/*
+scrape lists the SAs, connections and certificates at most once per scrape, so the collectors reading them share
+a single Vici request. The listings must not be modified by the collectors. A scrape is not safe for concurrent
+use, the collectors of a scrape run one after the other.
+*/
+type scrape struct {
+	viciClientFn viciClientFn
+	cache        *SasCache
+
+	sas       *listing[IkeSa]
+	conns     *listing[Conn]
+	certs     *listing[Cert]
+	x509Certs *listing[*x509.Certificate]
+}
+
+type listing[T any] struct {
+	items []T
+	err   error
+}
+
+func newScrape(viciClientFn viciClientFn, cache *SasCache) *scrape {
+	return &scrape{viciClientFn: viciClientFn, cache: cache}
+}
+
+// listSas returns the SAs from the SA cache, if enabled, or list-sas.
+func (s *scrape) listSas() ([]IkeSa, error) {
+	if s.sas == nil {
+		s.sas = &listing[IkeSa]{}
+		if s.cache != nil {
+			s.sas.items, s.sas.err = s.cache.list()
+		} else {
+			s.sas.items, s.sas.err = listSas(s.viciClientFn)
+		}
+	}
+	return s.sas.items, s.sas.err
+}
+
+func (s *scrape) listConns() ([]Conn, error) {
+	if s.conns == nil {
+		s.conns = &listing[Conn]{}
+		s.conns.items, s.conns.err = listConns(s.viciClientFn)
+	}
+	return s.conns.items, s.conns.err
+}
+
+// listCerts returns the loaded certificates of all types.
+func (s *scrape) listCerts() ([]Cert, error) {
+	if s.certs == nil {
+		s.certs = &listing[Cert]{}
+		s.certs.items, s.certs.err = listCerts(s.viciClientFn)
+	}
+	return s.certs.items, s.certs.err
+}
+
+// listX509Certs returns the parsed X509 certificates of the listed certificates.
+func (s *scrape) listX509Certs() ([]*x509.Certificate, error) {
+	if s.x509Certs == nil {
+		s.x509Certs = &listing[*x509.Certificate]{}
+		certs, err := s.listCerts()
+		if err == nil {
+			s.x509Certs.items = parseX509Certs(certs)
+		}
+		s.x509Certs.err = err
+	}
+	return s.x509Certs.items, s.x509Certs.err
+}
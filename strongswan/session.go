@@ -0,0 +1,296 @@
+package strongswan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+var (
+	errReconnectBackoff = errors.New("vici reconnect backoff in progress")
+	// errUnknownCommand is returned by the shared session for commands charon does not know, e.g. because the
+	// plugin is not loaded.
+	errUnknownCommand = errors.New("unknown vici command")
+)
+
+/*
+SessionManager keeps one long-lived Vici session shared by all collectors. A broken session is dropped
+on the first failed request and re-established on demand, with exponential backoff between failed
+connection attempts. Requests are serialized, govici does not lock the session while it streams. Every request
+is bounded by the timeout, so a hung charon cannot block the following scrapes.
+*/
+type SessionManager struct {
+	dialFn     viciClientFn
+	now        func() time.Time
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	timeout    time.Duration
+
+	// reqMu is held for the whole request, so concurrent scrapes do not interleave packets on the session.
+	reqMu sync.Mutex
+
+	mu          sync.Mutex
+	session     ViciClient
+	failures    int
+	nextAttempt time.Time
+	connects    int
+	errs        int
+	lastErrTime time.Time
+
+	connected      *prometheus.Desc
+	reconnectCnt   *prometheus.Desc
+	errCnt         *prometheus.Desc
+	lastErrSeconds *prometheus.Desc
+}
+
+func NewSessionManager(prefix string, dialFn viciClientFn, minBackoff time.Duration, maxBackoff time.Duration, timeout time.Duration, now func() time.Time) *SessionManager {
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	return &SessionManager{
+		dialFn:     dialFn,
+		now:        now,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		timeout:    timeout,
+
+		connected: prometheus.NewDesc(
+			prefix+"vici_session_connected",
+			"Flag if the Vici session is connected",
+			nil, nil,
+		),
+		reconnectCnt: prometheus.NewDesc(
+			prefix+"vici_session_reconnects_total",
+			"Number of times the Vici session was re-established",
+			nil, nil,
+		),
+		errCnt: prometheus.NewDesc(
+			prefix+"vici_session_errors_total",
+			"Number of failed Vici connection attempts and requests",
+			nil, nil,
+		),
+		lastErrSeconds: prometheus.NewDesc(
+			prefix+"vici_session_last_error_timestamp_seconds",
+			"Unix timestamp of the last Vici session error",
+			nil, nil,
+		),
+	}
+}
+
+// Client returns a handle to the shared session, connecting first if needed. Closing the handle
+// does not close the shared session.
+func (m *SessionManager) Client() (ViciClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.session != nil {
+		return &managedClient{m: m, s: m.session}, nil
+	}
+
+	now := m.now()
+	if now.Before(m.nextAttempt) {
+		return nil, errReconnectBackoff
+	}
+
+	s, err := m.dialFn()
+	if err != nil {
+		m.failures++
+		m.nextAttempt = now.Add(expBackoff(m.minBackoff, m.maxBackoff, m.failures))
+		m.recordErr(now)
+		return nil, err
+	}
+	if m.connects > 0 {
+		log.Logger.Info("Vici session re-established.")
+	}
+	m.session = s
+	m.failures = 0
+	m.nextAttempt = time.Time{}
+	m.connects++
+	return &managedClient{m: m, s: s}, nil
+}
+
+// Close closes the shared session.
+func (m *SessionManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.session == nil {
+		return nil
+	}
+	err := m.session.Close()
+	m.session = nil
+	return err
+}
+
+func (m *SessionManager) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.connected
+	ch <- m.reconnectCnt
+	ch <- m.errCnt
+	ch <- m.lastErrSeconds
+}
+
+func (m *SessionManager) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	connected := 0
+	if m.session != nil {
+		connected = 1
+	}
+	reconnects := max(m.connects-1, 0)
+	errs := m.errs
+	lastErrTime := m.lastErrTime
+	m.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(
+		m.connected,
+		prometheus.GaugeValue,
+		float64(connected),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		m.reconnectCnt,
+		prometheus.CounterValue,
+		float64(reconnects),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		m.errCnt,
+		prometheus.CounterValue,
+		float64(errs),
+	)
+	if !lastErrTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			m.lastErrSeconds,
+			prometheus.GaugeValue,
+			float64(lastErrTime.Unix()),
+		)
+	}
+}
+
+// invalidate drops the session after a failed request, unless it was already replaced.
+func (m *SessionManager) invalidate(s ViciClient, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.recordErr(m.now())
+	if m.session != s {
+		return
+	}
+	log.Logger.Warnf("Vici session failed, reconnecting on next request: %v", err)
+	_ = s.Close()
+	m.session = nil
+}
+
+func (m *SessionManager) recordErr(now time.Time) {
+	m.errs++
+	m.lastErrTime = now
+}
+
+// expBackoff returns the delay after the given number of consecutive failures.
+func expBackoff(minBackoff time.Duration, maxBackoff time.Duration, failures int) time.Duration {
+	d := minBackoff
+	for i := 1; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// viciCaller is implemented by *vici.Session, the context bounds the request.
+type viciCaller interface {
+	Call(ctx context.Context, cmd string, in *vici.Message) (*vici.Message, error)
+}
+
+// viciStreamer is implemented by *vici.Session, its iterator yields the response of a failed command with the error.
+type viciStreamer interface {
+	CallStreaming(ctx context.Context, cmd string, event string, in *vici.Message) iter.Seq2[*vici.Message, error]
+}
+
+type managedClient struct {
+	m *SessionManager
+	s ViciClient
+}
+
+func (mc *managedClient) CommandRequest(cmd string, msg *vici.Message) (*vici.Message, error) {
+	mc.m.reqMu.Lock()
+	defer mc.m.reqMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), mc.m.timeout)
+	defer cancel()
+	res, err := mc.call(ctx, cmd, msg)
+	if err != nil {
+		return res, mc.requestErr(cmd, res, err)
+	}
+	return res, nil
+}
+
+func (mc *managedClient) StreamedCommandRequest(cmd string, event string, msg *vici.Message) ([]*vici.Message, error) {
+	mc.m.reqMu.Lock()
+	defer mc.m.reqMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), mc.m.timeout)
+	defer cancel()
+	msgs, res, err := mc.stream(ctx, cmd, event, msg)
+	if err != nil {
+		return nil, mc.requestErr(cmd, res, err)
+	}
+	return msgs, nil
+}
+
+func (mc *managedClient) call(ctx context.Context, cmd string, msg *vici.Message) (*vici.Message, error) {
+	if vc, ok := mc.s.(viciCaller); ok {
+		return vc.Call(ctx, cmd, msg)
+	}
+	return mc.s.CommandRequest(cmd, msg)
+}
+
+// stream returns the streamed messages, or the response of the failed command if there is one.
+func (mc *managedClient) stream(ctx context.Context, cmd string, event string, msg *vici.Message) ([]*vici.Message, *vici.Message, error) {
+	vs, ok := mc.s.(viciStreamer)
+	if !ok {
+		msgs, err := mc.s.StreamedCommandRequest(cmd, event, msg)
+		return msgs, nil, err
+	}
+	msgs := make([]*vici.Message, 0)
+	for m, err := range vs.CallStreaming(ctx, cmd, event, msg) {
+		if err != nil {
+			return nil, m, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil, nil
+}
+
+// requestErr wraps the error of a failed request and drops the session if it is broken.
+func (mc *managedClient) requestErr(cmd string, res *vici.Message, err error) error {
+	// The response of a timed out request may still arrive and would be read by the next request.
+	if errors.Is(err, context.DeadlineExceeded) {
+		mc.m.invalidate(mc.s, err)
+		return fmt.Errorf("%s: %w", cmd, err)
+	}
+	// A failed command still has a response. Without one the session is broken or charon does not know the
+	// command, which govici does not report by a typed error, so the session is probed to tell them apart.
+	if res == nil {
+		if mc.probe() {
+			return fmt.Errorf("%s: %w", cmd, errUnknownCommand)
+		}
+		mc.m.invalidate(mc.s, err)
+	}
+	return fmt.Errorf("%s: %w", cmd, err)
+}
+
+// probe reports if the session still answers the version command, which every charon knows.
+func (mc *managedClient) probe() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), mc.m.timeout)
+	defer cancel()
+	_, err := mc.call(ctx, "version", nil)
+	return err == nil
+}
+
+// Close releases the handle, the shared session stays open.
+func (mc *managedClient) Close() error {
+	return nil
+}
@@ -0,0 +1,321 @@
+package strongswan
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"iter"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func TestSessionManager_SharedSession(t *testing.T) {
+	dialCalls := 0
+	fvc := &fakeViciClient{}
+	m := NewSessionManager("swtest_", func() (ViciClient, error) {
+		dialCalls++
+		return fvc, nil
+	}, time.Second, time.Minute, time.Second, time.Now)
+
+	for range 3 {
+		s, err := m.Client()
+		require.NoError(t, err)
+		_, err = s.StreamedCommandRequest("list-sas", "list-sa", nil)
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+	}
+	require.Equal(t, 1, dialCalls, "number of dial calls")
+	require.Equal(t, 0, fvc.closeTriggered, "number of session close calls")
+
+	require.NoError(t, m.Close())
+	require.Equal(t, 1, fvc.closeTriggered, "number of session close calls")
+}
+
+func TestSessionManager_Reconnect(t *testing.T) {
+	dialCalls := 0
+	fvc := &fakeViciClient{err: errors.New("broken pipe")}
+	m := NewSessionManager("swtest_", func() (ViciClient, error) {
+		dialCalls++
+		return fvc, nil
+	}, time.Second, time.Minute, time.Second, func() time.Time {
+		return time.Unix(1000, 0)
+	})
+
+	s, err := m.Client()
+	require.NoError(t, err)
+	_, err = s.StreamedCommandRequest("list-sas", "list-sa", nil)
+	require.Error(t, err)
+	require.Equal(t, 1, fvc.closeTriggered, "failed session must be closed")
+
+	fvc.err = nil
+	s, err = m.Client()
+	require.NoError(t, err)
+	_, err = s.StreamedCommandRequest("list-sas", "list-sa", nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, dialCalls, "number of dial calls")
+
+	want := `# HELP swtest_vici_session_connected Flag if the Vici session is connected
+# TYPE swtest_vici_session_connected gauge
+swtest_vici_session_connected 1
+# HELP swtest_vici_session_errors_total Number of failed Vici connection attempts and requests
+# TYPE swtest_vici_session_errors_total counter
+swtest_vici_session_errors_total 1
+# HELP swtest_vici_session_last_error_timestamp_seconds Unix timestamp of the last Vici session error
+# TYPE swtest_vici_session_last_error_timestamp_seconds gauge
+swtest_vici_session_last_error_timestamp_seconds 1000
+# HELP swtest_vici_session_reconnects_total Number of times the Vici session was re-established
+# TYPE swtest_vici_session_reconnects_total counter
+swtest_vici_session_reconnects_total 1
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestSessionManager_Backoff(t *testing.T) {
+	now := time.Unix(1000, 0)
+	dialCalls := 0
+	dialErr := errors.New("connection refused")
+	m := NewSessionManager("swtest_", func() (ViciClient, error) {
+		dialCalls++
+		return nil, dialErr
+	}, time.Second, 4*time.Second, time.Second, func() time.Time {
+		return now
+	})
+
+	// Expected waits after each failed attempt: 1s, 2s, 4s and capped at 4s.
+	for i, wait := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		_, err := m.Client()
+		require.ErrorIs(t, err, dialErr)
+		require.Equal(t, i+1, dialCalls, "number of dial calls")
+
+		now = now.Add(wait - time.Millisecond)
+		_, err = m.Client()
+		require.ErrorIs(t, err, errReconnectBackoff)
+		require.Equal(t, i+1, dialCalls, "no dial call during backoff")
+
+		now = now.Add(time.Millisecond)
+	}
+
+	cnt := testutil.CollectAndCount(m, "swtest_vici_session_connected")
+	require.Equal(t, 1, cnt, "metrics count")
+	if err := testutil.CollectAndCompare(m, strings.NewReader(`# HELP swtest_vici_session_connected Flag if the Vici session is connected
+# TYPE swtest_vici_session_connected gauge
+swtest_vici_session_connected 0
+`), "swtest_vici_session_connected"); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestSessionManager_FailedCommandKeepsSession(t *testing.T) {
+	dialCalls := 0
+	failed := vici.NewMessage()
+	failed.Set("success", "no")
+	fvc := &fakeViciClient{cmdMsgs: map[string]*vici.Message{"get-counters": failed}, err: errors.New("command failed")}
+	m := NewSessionManager("swtest_", func() (ViciClient, error) {
+		dialCalls++
+		return fvc, nil
+	}, time.Second, time.Minute, time.Second, time.Now)
+
+	s, err := m.Client()
+	require.NoError(t, err)
+	_, err = s.CommandRequest("get-counters", nil)
+	require.Error(t, err)
+	require.Equal(t, 0, fvc.closeTriggered, "session of a failed command must be kept")
+
+	// A request without response breaks the session.
+	_, err = s.CommandRequest("stats", nil)
+	require.Error(t, err)
+	require.Equal(t, 1, fvc.closeTriggered, "failed session must be closed")
+	_, err = m.Client()
+	require.NoError(t, err)
+	require.Equal(t, 2, dialCalls, "number of dial calls")
+}
+
+// fakeViciStreamer streams like *vici.Session, yielding the response of a failed command with the error.
+type fakeViciStreamer struct {
+	fakeViciClient
+	res *vici.Message
+}
+
+func (fvs *fakeViciStreamer) CallStreaming(_ context.Context, _ string, _ string, _ *vici.Message) iter.Seq2[*vici.Message, error] {
+	return func(yield func(*vici.Message, error) bool) {
+		yield(fvs.res, fvs.err)
+	}
+}
+
+func TestSessionManager_FailedStreamedCommandKeepsSession(t *testing.T) {
+	failed := vici.NewMessage()
+	failed.Set("success", "no")
+	fvs := &fakeViciStreamer{fakeViciClient: fakeViciClient{err: errors.New("command failed")}, res: failed}
+	m := NewSessionManager("swtest_", func() (ViciClient, error) {
+		return fvs, nil
+	}, time.Second, time.Minute, time.Second, time.Now)
+
+	s, err := m.Client()
+	require.NoError(t, err)
+	_, err = s.StreamedCommandRequest("list-sas", "list-sa", nil)
+	require.Error(t, err)
+	require.Equal(t, 0, fvs.closeTriggered, "session of a failed command must be kept")
+
+	// A stream without response breaks the session.
+	fvs.res = nil
+	_, err = s.StreamedCommandRequest("list-sas", "list-sa", nil)
+	require.Error(t, err)
+	require.Equal(t, 1, fvs.closeTriggered, "failed session must be closed")
+}
+
+// overlapDetector fails the test if a request starts while another one is in flight.
+type overlapDetector struct {
+	fakeViciClient
+	t        *testing.T
+	inFlight atomic.Int32
+}
+
+func (od *overlapDetector) StreamedCommandRequest(cmd string, event string, msg *vici.Message) ([]*vici.Message, error) {
+	if od.inFlight.Add(1) > 1 {
+		od.t.Error("concurrent requests on the shared session")
+	}
+	defer od.inFlight.Add(-1)
+	time.Sleep(time.Millisecond)
+	return od.fakeViciClient.StreamedCommandRequest(cmd, event, msg)
+}
+
+func TestSessionManager_SerializedRequests(t *testing.T) {
+	od := &overlapDetector{t: t}
+	m := NewSessionManager("swtest_", func() (ViciClient, error) {
+		return od, nil
+	}, time.Second, time.Minute, time.Second, time.Now)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := m.Client()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer s.Close()
+			_, _ = s.StreamedCommandRequest("list-sas", "list-sa", nil)
+		}()
+	}
+	wg.Wait()
+}
+
+// hungViciCaller does not answer requests until their context is done.
+type hungViciCaller struct {
+	fakeViciClient
+}
+
+func (hvc *hungViciCaller) Call(ctx context.Context, _ string, _ *vici.Message) (*vici.Message, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hvc *hungViciCaller) CallStreaming(ctx context.Context, _ string, _ string, _ *vici.Message) iter.Seq2[*vici.Message, error] {
+	return func(yield func(*vici.Message, error) bool) {
+		<-ctx.Done()
+		yield(nil, ctx.Err())
+	}
+}
+
+func TestSessionManager_Timeout(t *testing.T) {
+	dialCalls := 0
+	hvc := &hungViciCaller{}
+	m := NewSessionManager("swtest_", func() (ViciClient, error) {
+		dialCalls++
+		return hvc, nil
+	}, time.Second, time.Minute, 10*time.Millisecond, time.Now)
+
+	s, err := m.Client()
+	require.NoError(t, err)
+	_, err = s.CommandRequest("version", nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, hvc.closeTriggered, "timed out session must be closed")
+
+	s, err = m.Client()
+	require.NoError(t, err)
+	_, err = s.StreamedCommandRequest("list-sas", "list-sa", nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 2, hvc.closeTriggered, "timed out session must be closed")
+	require.Equal(t, 2, dialCalls)
+}
+
+// fakeCharonUnknownCommands answers the version command with an empty response, every other command with
+// CMD_UNKNOWN and confirms every event (un)registration.
+func fakeCharonUnknownCommands(t *testing.T) string {
+	const (
+		pktCmdRequest    = 0
+		pktCmdResponse   = 1
+		pktCmdUnknown    = 2
+		pktEventRegister = 3
+		pktEventConfirm  = 5
+	)
+	path := filepath.Join(t.TempDir(), "charon.vici")
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var length uint32
+			if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+				return
+			}
+			pkt := make([]byte, length)
+			if _, err := io.ReadFull(conn, pkt); err != nil {
+				return
+			}
+			res := byte(pktEventConfirm)
+			if pkt[0] == pktCmdRequest {
+				res = pktCmdUnknown
+				if name := pkt[2 : 2+int(pkt[1])]; string(name) == "version" {
+					res = pktCmdResponse
+				}
+			} else if pkt[0] != pktEventRegister && pkt[0] != pktEventRegister+1 {
+				return
+			}
+			if _, err := conn.Write([]byte{0, 0, 0, 1, res}); err != nil {
+				return
+			}
+		}
+	}()
+	return path
+}
+
+func TestSessionManager_GoviciUnknownCommand(t *testing.T) {
+	path := fakeCharonUnknownCommands(t)
+	dialCalls := 0
+	m := NewSessionManager("swtest_", func() (ViciClient, error) {
+		dialCalls++
+		return vici.NewSession(vici.WithAddr("unix", path))
+	}, time.Second, time.Minute, time.Second, time.Now)
+	defer m.Close()
+
+	s, err := m.Client()
+	require.NoError(t, err)
+	_, err = s.CommandRequest("get-counters", nil)
+	require.ErrorIs(t, err, errUnknownCommand)
+	_, err = s.StreamedCommandRequest("list-foo", "list-foo", nil)
+	require.ErrorIs(t, err, errUnknownCommand)
+
+	_, err = m.Client()
+	require.NoError(t, err)
+	require.Equal(t, 1, dialCalls, "session of an unknown command must be kept")
+}
@@ -0,0 +1,264 @@
+package strongswan
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+/*
+StatsCollector exposes the charon daemon statistics of the Vici stats command. A daemon restart is
+detected by the uptime going backwards between two scrapes. The start time is printed in the local
+time of charon, which is parsed in the given location.
+*/
+type StatsCollector struct {
+	viciClientFn viciClientFn
+	loc          *time.Location
+	now          func() time.Time
+
+	mu         sync.Mutex
+	lastUptime time.Duration
+	restarts   int
+
+	uptime         *prometheus.Desc
+	startTime      *prometheus.Desc
+	restartCnt     *prometheus.Desc
+	workers        *prometheus.Desc
+	workersIdle    *prometheus.Desc
+	workersActive  *prometheus.Desc
+	queuedJobs     *prometheus.Desc
+	scheduledJobs  *prometheus.Desc
+	ikeSas         *prometheus.Desc
+	ikeSasHalfOpen *prometheus.Desc
+	plugin         *prometheus.Desc
+	memBytes       *prometheus.Desc
+	memAllocs      *prometheus.Desc
+	mallinfoBytes  *prometheus.Desc
+}
+
+func NewStatsCollector(prefix string, viciClientFn viciClientFn, loc *time.Location, now func() time.Time) prometheus.Collector {
+	return &StatsCollector{
+		viciClientFn: viciClientFn,
+		loc:          loc,
+		now:          now,
+
+		uptime: prometheus.NewDesc(
+			prefix+"daemon_uptime_seconds",
+			"Seconds since the charon daemon was started",
+			nil, nil,
+		),
+		startTime: prometheus.NewDesc(
+			prefix+"daemon_start_timestamp_seconds",
+			"Unix timestamp when the charon daemon was started",
+			nil, nil,
+		),
+		restartCnt: prometheus.NewDesc(
+			prefix+"daemon_restarts_total",
+			"Number of charon daemon restarts detected by the exporter",
+			nil, nil,
+		),
+		workers: prometheus.NewDesc(
+			prefix+"daemon_worker_threads",
+			"Number of worker threads",
+			nil, nil,
+		),
+		workersIdle: prometheus.NewDesc(
+			prefix+"daemon_worker_threads_idle",
+			"Number of idle worker threads",
+			nil, nil,
+		),
+		workersActive: prometheus.NewDesc(
+			prefix+"daemon_worker_threads_active",
+			"Number of worker threads processing jobs of the priority",
+			[]string{"priority"}, nil,
+		),
+		queuedJobs: prometheus.NewDesc(
+			prefix+"daemon_queued_jobs",
+			"Number of queued jobs of the priority",
+			[]string{"priority"}, nil,
+		),
+		scheduledJobs: prometheus.NewDesc(
+			prefix+"daemon_scheduled_jobs",
+			"Number of jobs scheduled for timed execution",
+			nil, nil,
+		),
+		ikeSas: prometheus.NewDesc(
+			prefix+"daemon_ike_sas",
+			"Number of IKE SAs in the IKE SA manager",
+			nil, nil,
+		),
+		ikeSasHalfOpen: prometheus.NewDesc(
+			prefix+"daemon_ike_sas_half_open",
+			"Number of half-open IKE SAs",
+			nil, nil,
+		),
+		plugin: prometheus.NewDesc(
+			prefix+"daemon_plugin_loaded",
+			"Plugins loaded by the charon daemon",
+			[]string{"plugin"}, nil,
+		),
+		memBytes: prometheus.NewDesc(
+			prefix+"daemon_memory_bytes",
+			"Heap memory usage, only available with leak-detective",
+			nil, nil,
+		),
+		memAllocs: prometheus.NewDesc(
+			prefix+"daemon_memory_allocations",
+			"Number of heap allocation blocks, only available with leak-detective",
+			nil, nil,
+		),
+		mallinfoBytes: prometheus.NewDesc(
+			prefix+"daemon_mallinfo_bytes",
+			"Memory statistics of mallinfo() by type",
+			[]string{"type"}, nil,
+		),
+	}
+}
+
+func (c *StatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.uptime
+	ch <- c.startTime
+	ch <- c.restartCnt
+	ch <- c.workers
+	ch <- c.workersIdle
+	ch <- c.workersActive
+	ch <- c.queuedJobs
+	ch <- c.scheduledJobs
+	ch <- c.ikeSas
+	ch <- c.ikeSasHalfOpen
+	ch <- c.plugin
+	ch <- c.memBytes
+	ch <- c.memAllocs
+	ch <- c.mallinfoBytes
+}
+
+func (c *StatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.stats()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		c.collectStatsMetrics(stats, ch)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.restartCnt,
+		prometheus.CounterValue,
+		float64(c.restarts),
+	)
+}
+
+func (c *StatsCollector) collectStatsMetrics(stats Stats, ch chan<- prometheus.Metric) {
+	if since, err := parseViciTime(stats.Uptime.Since, c.loc); err == nil {
+		uptime := c.now().Sub(since)
+		if c.lastUptime > 0 && uptime < c.lastUptime {
+			log.Logger.Infof("Daemon restart detected, started at %v.", since)
+			c.restarts++
+		}
+		c.lastUptime = uptime
+		ch <- prometheus.MustNewConstMetric(
+			c.uptime,
+			prometheus.GaugeValue,
+			uptime.Seconds(),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.startTime,
+			prometheus.GaugeValue,
+			float64(since.Unix()),
+		)
+	} else {
+		log.Logger.Warnf("Daemon start time parse error: %v", err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.workers,
+		prometheus.GaugeValue,
+		float64(stats.Workers.Total),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.workersIdle,
+		prometheus.GaugeValue,
+		float64(stats.Workers.Idle),
+	)
+	for priority, v := range stats.Workers.Active {
+		ch <- prometheus.MustNewConstMetric(
+			c.workersActive,
+			prometheus.GaugeValue,
+			float64(v),
+			priority,
+		)
+	}
+	for priority, v := range stats.Queues {
+		ch <- prometheus.MustNewConstMetric(
+			c.queuedJobs,
+			prometheus.GaugeValue,
+			float64(v),
+			priority,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.scheduledJobs,
+		prometheus.GaugeValue,
+		float64(stats.Scheduled),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.ikeSas,
+		prometheus.GaugeValue,
+		float64(stats.IkeSas.Total),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.ikeSasHalfOpen,
+		prometheus.GaugeValue,
+		float64(stats.IkeSas.HalfOpen),
+	)
+	for _, plugin := range stats.Plugins {
+		ch <- prometheus.MustNewConstMetric(
+			c.plugin,
+			prometheus.GaugeValue,
+			1,
+			plugin,
+		)
+	}
+	if stats.Mem != nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.memBytes,
+			prometheus.GaugeValue,
+			float64(stats.Mem.Total),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.memAllocs,
+			prometheus.GaugeValue,
+			float64(stats.Mem.Allocs),
+		)
+	}
+	for typ, v := range stats.Mallinfo {
+		ch <- prometheus.MustNewConstMetric(
+			c.mallinfoBytes,
+			prometheus.GaugeValue,
+			float64(v),
+			typ,
+		)
+	}
+}
+
+func (c *StatsCollector) stats() (Stats, error) {
+	var stats Stats
+	s, err := c.viciClientFn()
+	if err != nil {
+		return stats, err
+	}
+	defer s.Close()
+
+	m, err := s.CommandRequest("stats", vici.NewMessage())
+	if err != nil {
+		return stats, err
+	}
+	if err = vici.UnmarshalMessage(m, &stats); err != nil {
+		log.Logger.Warnf("Message unmarshal error: %v", err)
+		return stats, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,233 @@
+package strongswan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func statsMsg(since string) *vici.Message {
+	uptime := vici.NewMessage()
+	uptime.Set("running", "5 minutes")
+	uptime.Set("since", since)
+	active := vici.NewMessage()
+	active.Set("critical", 4)
+	active.Set("high", 1)
+	active.Set("medium", 2)
+	active.Set("low", 0)
+	workers := vici.NewMessage()
+	workers.Set("total", 16)
+	workers.Set("idle", 9)
+	workers.Set("active", active)
+	queues := vici.NewMessage()
+	queues.Set("critical", 0)
+	queues.Set("high", 0)
+	queues.Set("medium", 3)
+	queues.Set("low", 0)
+	ikesas := vici.NewMessage()
+	ikesas.Set("total", 12)
+	ikesas.Set("half-open", 2)
+
+	m := vici.NewMessage()
+	m.Set("uptime", uptime)
+	m.Set("workers", workers)
+	m.Set("queues", queues)
+	m.Set("scheduled", 7)
+	m.Set("ikesas", ikesas)
+	m.Set("plugins", []string{"random", "nonce", "kernel-netlink"})
+	return m
+}
+
+func TestStatsCollector_Metrics(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 5, 0, 0, time.Local)
+	tests := []struct {
+		name              string
+		viciClientErr     error
+		msgModifierFn     func(m *vici.Message)
+		metricName        string
+		wantMetricsHelp   string
+		wantMetricsType   string
+		wantMetricsLabels string
+		wantMetricsValue  float64
+		wantMetricsCount  int
+	}{
+		{
+			name:             "connection error",
+			viciClientErr:    errors.New("some error"),
+			metricName:       "swtest_daemon_restarts_total",
+			wantMetricsHelp:  "Number of charon daemon restarts detected by the exporter",
+			wantMetricsType:  "counter",
+			wantMetricsValue: 0,
+			wantMetricsCount: 1,
+		},
+		{
+			name:             "uptime",
+			metricName:       "swtest_daemon_uptime_seconds",
+			wantMetricsHelp:  "Seconds since the charon daemon was started",
+			wantMetricsType:  "gauge",
+			wantMetricsValue: 300,
+			wantMetricsCount: 19,
+		},
+		{
+			name:             "start time",
+			metricName:       "swtest_daemon_start_timestamp_seconds",
+			wantMetricsHelp:  "Unix timestamp when the charon daemon was started",
+			wantMetricsType:  "gauge",
+			wantMetricsValue: float64(now.Add(-5 * time.Minute).Unix()),
+			wantMetricsCount: 19,
+		},
+		{
+			name:             "idle workers",
+			metricName:       "swtest_daemon_worker_threads_idle",
+			wantMetricsHelp:  "Number of idle worker threads",
+			wantMetricsType:  "gauge",
+			wantMetricsValue: 9,
+			wantMetricsCount: 19,
+		},
+		{
+			name:             "half-open IKE SAs",
+			metricName:       "swtest_daemon_ike_sas_half_open",
+			wantMetricsHelp:  "Number of half-open IKE SAs",
+			wantMetricsType:  "gauge",
+			wantMetricsValue: 2,
+			wantMetricsCount: 19,
+		},
+		{
+			name: "leak-detective memory",
+			msgModifierFn: func(m *vici.Message) {
+				mem := vici.NewMessage()
+				mem.Set("total", 2048)
+				mem.Set("allocs", 12)
+				m.Set("mem", mem)
+			},
+			metricName:       "swtest_daemon_memory_bytes",
+			wantMetricsHelp:  "Heap memory usage, only available with leak-detective",
+			wantMetricsType:  "gauge",
+			wantMetricsValue: 2048,
+			wantMetricsCount: 21,
+		},
+		{
+			name: "mallinfo",
+			msgModifierFn: func(m *vici.Message) {
+				mallinfo := vici.NewMessage()
+				mallinfo.Set("used", 4096)
+				m.Set("mallinfo", mallinfo)
+			},
+			metricName:        "swtest_daemon_mallinfo_bytes",
+			wantMetricsHelp:   "Memory statistics of mallinfo() by type",
+			wantMetricsType:   "gauge",
+			wantMetricsLabels: `type="used"`,
+			wantMetricsValue:  4096,
+			wantMetricsCount:  20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := statsMsg("Oct 16 10:00:00 2026")
+			if tt.msgModifierFn != nil {
+				tt.msgModifierFn(m)
+			}
+			c := NewStatsCollector("swtest_", func() (ViciClient, error) {
+				return &fakeViciClient{cmdMsgs: map[string]*vici.Message{"stats": m}}, tt.viciClientErr
+			}, time.Local, func() time.Time {
+				return now
+			})
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
+
+			wantMetricsContent := fmt.Sprintf(`# HELP %s %s
+# TYPE %s %s
+%s{%s} %v
+`, tt.metricName, tt.wantMetricsHelp, tt.metricName, tt.wantMetricsType, tt.metricName, tt.wantMetricsLabels, tt.wantMetricsValue)
+			if err := testutil.CollectAndCompare(c, strings.NewReader(wantMetricsContent), tt.metricName); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
+
+func TestStatsCollector_Restarts(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 5, 0, 0, time.Local)
+	fvc := &fakeViciClient{cmdMsgs: map[string]*vici.Message{"stats": statsMsg("Oct 16 10:00:00 2026")}}
+	c := NewStatsCollector("swtest_", func() (ViciClient, error) {
+		return fvc, nil
+	}, time.Local, func() time.Time {
+		return now
+	})
+	assertRestarts := func(want int) {
+		t.Helper()
+		wantContent := fmt.Sprintf(`# HELP swtest_daemon_restarts_total Number of charon daemon restarts detected by the exporter
+# TYPE swtest_daemon_restarts_total counter
+swtest_daemon_restarts_total %d
+`, want)
+		if err := testutil.CollectAndCompare(c, strings.NewReader(wantContent), "swtest_daemon_restarts_total"); err != nil {
+			t.Errorf("unexpected collecting result:\n%s", err)
+		}
+	}
+
+	assertRestarts(0)
+	now = now.Add(time.Minute)
+	assertRestarts(0)
+
+	// The daemon was restarted a minute ago, the uptime went back from 6 to 1 minute.
+	fvc.cmdMsgs["stats"] = statsMsg("Oct 16 10:05:00 2026")
+	assertRestarts(1)
+
+	// A failing stats command does not count as restart.
+	fvc.err = errors.New("some error")
+	assertRestarts(1)
+	fvc.err = nil
+	now = now.Add(time.Minute)
+	assertRestarts(1)
+}
+
+func TestStatsCollector_Priorities(t *testing.T) {
+	c := NewStatsCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{cmdMsgs: map[string]*vici.Message{"stats": statsMsg("Oct 16 10:00:00 2026")}}, nil
+	}, time.Local, time.Now)
+
+	want := `# HELP swtest_daemon_queued_jobs Number of queued jobs of the priority
+# TYPE swtest_daemon_queued_jobs gauge
+swtest_daemon_queued_jobs{priority="critical"} 0
+swtest_daemon_queued_jobs{priority="high"} 0
+swtest_daemon_queued_jobs{priority="low"} 0
+swtest_daemon_queued_jobs{priority="medium"} 3
+# HELP swtest_daemon_worker_threads_active Number of worker threads processing jobs of the priority
+# TYPE swtest_daemon_worker_threads_active gauge
+swtest_daemon_worker_threads_active{priority="critical"} 4
+swtest_daemon_worker_threads_active{priority="high"} 1
+swtest_daemon_worker_threads_active{priority="low"} 0
+swtest_daemon_worker_threads_active{priority="medium"} 2
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(want), "swtest_daemon_queued_jobs", "swtest_daemon_worker_threads_active")
+	if err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestStatsCollector_CharonLocation(t *testing.T) {
+	// charon runs in CEST, the exporter in UTC.
+	c := NewStatsCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{cmdMsgs: map[string]*vici.Message{"stats": statsMsg("Oct 16 12:00:00 2026")}}, nil
+	}, time.FixedZone("CEST", 2*60*60), func() time.Time {
+		return time.Date(2026, 10, 16, 10, 5, 0, 0, time.UTC)
+	})
+
+	want := `# HELP swtest_daemon_start_timestamp_seconds Unix timestamp when the charon daemon was started
+# TYPE swtest_daemon_start_timestamp_seconds gauge
+swtest_daemon_start_timestamp_seconds 1.7921448e+09
+# HELP swtest_daemon_uptime_seconds Seconds since the charon daemon was started
+# TYPE swtest_daemon_uptime_seconds gauge
+swtest_daemon_uptime_seconds 300
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "swtest_daemon_start_timestamp_seconds", "swtest_daemon_uptime_seconds"); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
@@ -41,6 +41,8 @@ type ChildIkeSa struct {
 	Mode         string   `vici:"mode"`
 	Protocol     string   `vici:"protocol"`
 	Encap        string   `vici:"encap"`
+	SpiIn        string   `vici:"spi-in"`
+	SpiOut       string   `vici:"spi-out"`
 	EncAlg       string   `vici:"encr-alg"`
 	EncKey       int      `vici:"encr-keysize"`
 	IntegAlg     string   `vici:"integ-alg"`
@@ -65,9 +67,13 @@ type ChildIkeSa struct {
 Cert documentation: https://github.com/strongswan/strongswan/blob/master/src/libcharon/plugins/vici/README.md#list-cert
 */
 type Cert struct {
-	Type  string `vici:"type"`
-	Flags string `vici:"flags"`
-	Data  string `vici:"data"`
+	Type       string `vici:"type"`
+	Flag       string `vici:"flag"`
+	HasPrivkey string `vici:"has_privkey"`
+	Data       string `vici:"data"`
+	Subject    string `vici:"subject"`
+	NotBefore  string `vici:"not-before"`
+	NotAfter   string `vici:"not-after"`
 }
 
 /*
@@ -83,6 +89,17 @@ type Conn struct {
 	ReauthTime  int64                `vici:"reauth_time"`
 	RekeyTime   int64                `vici:"rekey_time"`
 	Children    map[string]ConnChild `vici:"children"`
+	LocalAuths  []ConnAuth
+	RemoteAuths []ConnAuth
+}
+
+// ConnAuth is a local-<round> or remote-<round> authentication round of a connection.
+type ConnAuth struct {
+	Round   string
+	Class   string   `vici:"class"`
+	ID      string   `vici:"id"`
+	Certs   []string `vici:"certs"`
+	CaCerts []string `vici:"cacerts"`
 }
 
 type ConnChild struct {
@@ -94,3 +111,98 @@ type ConnChild struct {
 	LocalTS      []string `vici:"local-ts"`
 	RemoteTS     []string `vici:"remote-ts"`
 }
+
+/*
+Stats documentation: https://github.com/strongswan/strongswan/blob/master/src/libcharon/plugins/vici/README.md#stats
+*/
+type Stats struct {
+	Uptime    StatsUptime      `vici:"uptime"`
+	Workers   StatsWorkers     `vici:"workers"`
+	Queues    map[string]int64 `vici:"queues"`
+	Scheduled int64            `vici:"scheduled"`
+	IkeSas    StatsIkeSas      `vici:"ikesas"`
+	Plugins   []string         `vici:"plugins"`
+	Mem       *StatsMem        `vici:"mem"`
+	Mallinfo  map[string]int64 `vici:"mallinfo"`
+}
+
+type StatsUptime struct {
+	Running string `vici:"running"`
+	Since   string `vici:"since"`
+}
+
+type StatsWorkers struct {
+	Total  int64            `vici:"total"`
+	Idle   int64            `vici:"idle"`
+	Active map[string]int64 `vici:"active"`
+}
+
+type StatsIkeSas struct {
+	Total    int64 `vici:"total"`
+	HalfOpen int64 `vici:"half-open"`
+}
+
+type StatsMem struct {
+	Total  int64 `vici:"total"`
+	Allocs int64 `vici:"allocs"`
+}
+
+/*
+Version documentation: https://github.com/strongswan/strongswan/blob/master/src/libcharon/plugins/vici/README.md#version
+*/
+type Version struct {
+	Daemon  string `vici:"daemon"`
+	Version string `vici:"version"`
+	Sysname string `vici:"sysname"`
+	Release string `vici:"release"`
+	Machine string `vici:"machine"`
+}
+
+/*
+Pool documentation: https://github.com/strongswan/strongswan/blob/master/src/libcharon/plugins/vici/README.md#list-pools
+*/
+type Pool struct {
+	Name    string
+	Base    string               `vici:"base"`
+	Size    int64                `vici:"size"`
+	Online  int64                `vici:"online"`
+	Offline int64                `vici:"offline"`
+	Leases  map[string]PoolLease `vici:"leases"`
+}
+
+type PoolLease struct {
+	Address  string `vici:"address"`
+	Identity string `vici:"identity"`
+	Status   string `vici:"status"`
+}
+
+/*
+Policy documentation: https://github.com/strongswan/strongswan/blob/master/src/libcharon/plugins/vici/README.md#list-policy
+*/
+type Policy struct {
+	Name     string
+	Child    string   `vici:"child"`
+	Ike      string   `vici:"ike"`
+	Mode     string   `vici:"mode"`
+	LocalTS  []string `vici:"local-ts"`
+	RemoteTS []string `vici:"remote-ts"`
+}
+
+/*
+Authority documentation: https://github.com/strongswan/strongswan/blob/master/src/libcharon/plugins/vici/README.md#list-authority
+*/
+type Authority struct {
+	Name        string
+	CaCert      string   `vici:"cacert"`
+	CrlURIs     []string `vici:"crl_uris"`
+	OcspURIs    []string `vici:"ocsp_uris"`
+	CertURIBase string   `vici:"cert_uri_base"`
+}
+
+/*
+CredKeys documentation: https://github.com/strongswan/strongswan/blob/master/src/libcharon/plugins/vici/README.md#get-keys
+and https://github.com/strongswan/strongswan/blob/master/src/libcharon/plugins/vici/README.md#get-shared
+*/
+type CredKeys struct {
+	Keys []string `vici:"keys"`
+}
@@ -0,0 +1,471 @@
+package strongswan
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+// Credential subdirectories of the swanctl directory which swanctl --load-creds loads into charon.
+var (
+	swanctlCertDirs = []string{"x509", "x509ca"}
+	swanctlCrlDirs  = []string{"x509crl"}
+	swanctlKeyDirs  = []string{"private", "rsa", "ecdsa", "pkcs8"}
+)
+
+const (
+	fileTypeCert = "cert"
+	fileTypeCrl  = "crl"
+	fileTypeKey  = "key"
+)
+
+// swanctlFiles are the parsed credential files of the swanctl directory, paths are relative to it.
+type swanctlFiles struct {
+	cnt           map[string]int
+	certs         []swanctlCert
+	crls          []swanctlCrl
+	keys          []swanctlKey
+	encryptedKeys []swanctlKey
+}
+
+type swanctlCert struct {
+	path string
+	cert *x509.Certificate
+}
+
+type swanctlCrl struct {
+	path string
+	crl  *x509.RevocationList
+}
+
+type swanctlKey struct {
+	path      string
+	keyID     string
+	encrypted bool
+}
+
+// add adds the parsed file, a swanctlCert, swanctlCrl or swanctlKey, nil if it failed to parse.
+func (f *swanctlFiles) add(parsed any) {
+	switch v := parsed.(type) {
+	case swanctlCert:
+		f.certs = append(f.certs, v)
+	case swanctlCrl:
+		f.crls = append(f.crls, v)
+	case swanctlKey:
+		if v.encrypted {
+			f.encryptedKeys = append(f.encryptedKeys, v)
+		} else {
+			f.keys = append(f.keys, v)
+		}
+	}
+}
+
+// swanctlFile is the parse result of a credential file, which is reused while the file is unchanged.
+type swanctlFile struct {
+	modTime time.Time
+	size    int64
+	parsed  any
+}
+
+/*
+loadedCreds are the certificates, CRLs and private key identifiers loaded into charon. ownCerts are the
+certificates with a private key and the CA certificates, the other certificates are received from peers.
+*/
+type loadedCreds struct {
+	certs    []*x509.Certificate
+	ownCerts []*x509.Certificate
+	crls     [][]byte
+	keyIDs   []string
+}
+
+/*
+SwanctlDirCollector scans the credential directories of a swanctl configuration directory (e.g. /etc/swanctl)
+and compares the files with the credentials loaded into charon. The expiry of the files is exported even if
+charon is not reachable, so certificates which are only loaded after a restart are covered as well. Files are
+only parsed again if their modification time or size changed.
+*/
+type SwanctlDirCollector struct {
+	viciClientFn viciClientFn
+	dir          string
+	now          func() time.Time
+
+	mu    sync.Mutex
+	files map[string]swanctlFile
+
+	fileCnt               *prometheus.Desc
+	encryptedKeyCnt       *prometheus.Desc
+	certExpireSecs        *prometheus.Desc
+	crlExpireSecs         *prometheus.Desc
+	fileLoaded            *prometheus.Desc
+	certLoadedWithoutFile *prometheus.Desc
+	keyLoadedWithoutFile  *prometheus.Desc
+	certKeyMatch          *prometheus.Desc
+}
+
+func NewSwanctlDirCollector(prefix string, viciClientFn viciClientFn, dir string, now func() time.Time) prometheus.Collector {
+	return &SwanctlDirCollector{
+		viciClientFn: viciClientFn,
+		dir:          dir,
+		now:          now,
+		files:        make(map[string]swanctlFile),
+
+		fileCnt: prometheus.NewDesc(
+			prefix+"swanctl_files",
+			"Number of files in the credential directory of the swanctl directory",
+			[]string{"dir"}, nil,
+		),
+		encryptedKeyCnt: prometheus.NewDesc(
+			prefix+"swanctl_encrypted_keys",
+			"Number of encrypted private key files, which are not compared with the loaded private keys",
+			nil, nil,
+		),
+		certExpireSecs: prometheus.NewDesc(
+			prefix+"swanctl_cert_expire_seconds",
+			"Seconds until the X509 certificate file expires",
+			[]string{"path", "serial_number", "subject"}, nil,
+		),
+		crlExpireSecs: prometheus.NewDesc(
+			prefix+"swanctl_crl_expire_seconds",
+			"Seconds until the next update of the CRL file",
+			[]string{"path", "issuer"}, nil,
+		),
+		fileLoaded: prometheus.NewDesc(
+			prefix+"swanctl_file_loaded",
+			"Flag if the credential file is loaded into charon",
+			[]string{"path", "type"}, nil,
+		),
+		certLoadedWithoutFile: prometheus.NewDesc(
+			prefix+"swanctl_cert_loaded_without_file",
+			"Loaded X509 certificate which is not found in the swanctl directory",
+			[]string{"serial_number", "subject"}, nil,
+		),
+		keyLoadedWithoutFile: prometheus.NewDesc(
+			prefix+"swanctl_key_loaded_without_file",
+			"Loaded private key which is not found in the swanctl directory",
+			[]string{"key_id"}, nil,
+		),
+		certKeyMatch: prometheus.NewDesc(
+			prefix+"swanctl_cert_key_match",
+			"Flag if the private key file with the same name as the certificate file belongs to the certificate",
+			[]string{"cert_path", "key_path"}, nil,
+		),
+	}
+}
+
+func (c *SwanctlDirCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.fileCnt
+	ch <- c.encryptedKeyCnt
+	ch <- c.certExpireSecs
+	ch <- c.crlExpireSecs
+	ch <- c.fileLoaded
+	ch <- c.certLoadedWithoutFile
+	ch <- c.keyLoadedWithoutFile
+	ch <- c.certKeyMatch
+}
+
+func (c *SwanctlDirCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, newScrape(c.viciClientFn, nil))
+}
+
+func (c *SwanctlDirCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	files := c.scan()
+	c.collectFileMetrics(files, c.now(), ch)
+
+	loaded, err := c.loadedCreds(s)
+	if err != nil {
+		return
+	}
+	c.collectLoadedMetrics(files, loaded, ch)
+}
+
+func (c *SwanctlDirCollector) collectFileMetrics(files swanctlFiles, now time.Time, ch chan<- prometheus.Metric) {
+	for _, dir := range slices.Concat(swanctlCertDirs, swanctlCrlDirs, swanctlKeyDirs) {
+		ch <- prometheus.MustNewConstMetric(
+			c.fileCnt,
+			prometheus.GaugeValue,
+			float64(files.cnt[dir]),
+			dir,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.encryptedKeyCnt,
+		prometheus.GaugeValue,
+		float64(len(files.encryptedKeys)),
+	)
+	for _, f := range files.certs {
+		ch <- prometheus.MustNewConstMetric(
+			c.certExpireSecs,
+			prometheus.GaugeValue,
+			f.cert.NotAfter.Sub(now).Seconds(),
+			f.path, formatSerialNumber(f.cert.SerialNumber), f.cert.Subject.String(),
+		)
+	}
+	for _, f := range files.crls {
+		if f.crl.NextUpdate.IsZero() {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.crlExpireSecs,
+			prometheus.GaugeValue,
+			f.crl.NextUpdate.Sub(now).Seconds(),
+			f.path, f.crl.Issuer.String(),
+		)
+	}
+	for _, cf := range files.certs {
+		if path.Dir(cf.path) != "x509" {
+			continue
+		}
+		for _, kf := range files.keys {
+			if fileBaseName(kf.path) != fileBaseName(cf.path) {
+				continue
+			}
+			match := 0
+			if kf.keyID == keyID(cf.cert) {
+				match = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.certKeyMatch,
+				prometheus.GaugeValue,
+				float64(match),
+				cf.path, kf.path,
+			)
+		}
+	}
+}
+
+func (c *SwanctlDirCollector) collectLoadedMetrics(files swanctlFiles, loaded loadedCreds, ch chan<- prometheus.Metric) {
+	flag := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	for _, f := range files.certs {
+		isLoaded := slices.ContainsFunc(loaded.certs, func(cert *x509.Certificate) bool {
+			return cert.Equal(f.cert)
+		})
+		ch <- prometheus.MustNewConstMetric(c.fileLoaded, prometheus.GaugeValue, flag(isLoaded), f.path, fileTypeCert)
+	}
+	for _, f := range files.crls {
+		isLoaded := slices.ContainsFunc(loaded.crls, func(crl []byte) bool {
+			return bytes.Equal(crl, f.crl.Raw)
+		})
+		ch <- prometheus.MustNewConstMetric(c.fileLoaded, prometheus.GaugeValue, flag(isLoaded), f.path, fileTypeCrl)
+	}
+	for _, f := range files.keys {
+		isLoaded := slices.Contains(loaded.keyIDs, f.keyID)
+		ch <- prometheus.MustNewConstMetric(c.fileLoaded, prometheus.GaugeValue, flag(isLoaded), f.path, fileTypeKey)
+	}
+
+	for _, cert := range loaded.ownCerts {
+		onDisk := slices.ContainsFunc(files.certs, func(f swanctlCert) bool {
+			return f.cert.Equal(cert)
+		})
+		if !onDisk {
+			ch <- prometheus.MustNewConstMetric(
+				c.certLoadedWithoutFile,
+				prometheus.GaugeValue,
+				1,
+				formatSerialNumber(cert.SerialNumber), cert.Subject.String(),
+			)
+		}
+	}
+	for _, id := range loaded.keyIDs {
+		onDisk := slices.ContainsFunc(files.keys, func(f swanctlKey) bool {
+			return f.keyID == id
+		})
+		if !onDisk {
+			ch <- prometheus.MustNewConstMetric(
+				c.keyLoadedWithoutFile,
+				prometheus.GaugeValue,
+				1,
+				id,
+			)
+		}
+	}
+}
+
+func (c *SwanctlDirCollector) loadedCreds(sc *scrape) (loadedCreds, error) {
+	var loaded loadedCreds
+	s, err := c.viciClientFn()
+	if err != nil {
+		return loaded, err
+	}
+	defer s.Close()
+
+	var keys CredKeys
+	if err := commandRequest(s, "get-keys", &keys); err != nil {
+		return loaded, err
+	}
+	certs, err := sc.listCerts()
+	if err != nil {
+		return loaded, err
+	}
+
+	for _, vc := range certs {
+		if vc.Type == typeX509CRL {
+			loaded.crls = append(loaded.crls, []byte(vc.Data))
+			continue
+		}
+		if vc.Type != typeX509Cert {
+			continue
+		}
+		cert, err := x509.ParseCertificate([]byte(vc.Data))
+		if err != nil {
+			log.Logger.Warnf("Certificate parse error: %v", err)
+			continue
+		}
+		loaded.certs = append(loaded.certs, cert)
+		if vc.HasPrivkey == "yes" || isCACert(vc, cert) {
+			loaded.ownCerts = append(loaded.ownCerts, cert)
+		}
+	}
+	loaded.keyIDs = keys.Keys
+	return loaded, nil
+}
+
+/*
+scan parses the credential files of the swanctl directory, files which fail to parse are skipped. The parse
+results of unchanged files are reused, so a broken file is only reported once.
+*/
+func (c *SwanctlDirCollector) scan() swanctlFiles {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files := swanctlFiles{cnt: make(map[string]int)}
+	scanned := make(map[string]swanctlFile, len(c.files))
+	scan := func(subdirs []string, parse func(relPath string, data []byte) (any, error)) {
+		for _, subdir := range subdirs {
+			entries, err := os.ReadDir(filepath.Join(c.dir, subdir))
+			if err != nil {
+				log.Logger.Debugf("Swanctl directory not readable: %v", err)
+				continue
+			}
+			for _, e := range entries {
+				if !e.Type().IsRegular() {
+					continue
+				}
+				files.cnt[subdir]++
+				relPath := path.Join(subdir, e.Name())
+				info, err := e.Info()
+				if err != nil {
+					log.Logger.Warnf("Swanctl file stat error: %v", err)
+					continue
+				}
+				f, ok := c.files[relPath]
+				if !ok || !f.modTime.Equal(info.ModTime()) || f.size != info.Size() {
+					data, err := os.ReadFile(filepath.Join(c.dir, subdir, e.Name()))
+					if err != nil {
+						log.Logger.Warnf("Swanctl file read error: %v", err)
+						continue
+					}
+					f = swanctlFile{modTime: info.ModTime(), size: info.Size()}
+					if f.parsed, err = parse(relPath, data); err != nil {
+						log.Logger.Warnf("Swanctl file '%s' parse error: %v", relPath, err)
+					}
+				}
+				scanned[relPath] = f
+				files.add(f.parsed)
+			}
+		}
+	}
+	scan(swanctlCertDirs, func(relPath string, data []byte) (any, error) {
+		cert, err := x509.ParseCertificate(fileDER(data))
+		if err != nil {
+			return nil, err
+		}
+		return swanctlCert{path: relPath, cert: cert}, nil
+	})
+	scan(swanctlCrlDirs, func(relPath string, data []byte) (any, error) {
+		crl, err := x509.ParseRevocationList(fileDER(data))
+		if err != nil {
+			return nil, err
+		}
+		return swanctlCrl{path: relPath, crl: crl}, nil
+	})
+	scan(swanctlKeyDirs, func(relPath string, data []byte) (any, error) {
+		if isEncryptedKey(data) {
+			log.Logger.Debugf("Swanctl file '%s' is an encrypted private key, skipped.", relPath)
+			return swanctlKey{path: relPath, encrypted: true}, nil
+		}
+		id, err := privateKeyID(fileDER(data))
+		if err != nil {
+			return nil, err
+		}
+		return swanctlKey{path: relPath, keyID: id}, nil
+	})
+	c.files = scanned
+	return files
+}
+
+// fileDER returns the content of the first PEM block, or the data itself if it is DER encoded.
+func fileDER(data []byte) []byte {
+	if block, _ := pem.Decode(data); block != nil {
+		return block.Bytes
+	}
+	return data
+}
+
+// privateKeyID returns the key identifier of an unencrypted PKCS#8, PKCS#1 RSA or SEC1 EC private key.
+func privateKeyID(der []byte) (string, error) {
+	parsers := []func([]byte) (any, error){
+		x509.ParsePKCS8PrivateKey,
+		func(der []byte) (any, error) { return x509.ParsePKCS1PrivateKey(der) },
+		func(der []byte) (any, error) { return x509.ParseECPrivateKey(der) },
+	}
+	for _, parse := range parsers {
+		key, err := parse(der)
+		if err != nil {
+			continue
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return "", errors.New("unsupported private key type")
+		}
+		spki, err := x509.MarshalPKIXPublicKey(signer.Public())
+		if err != nil {
+			return "", err
+		}
+		return publicKeyID(spki), nil
+	}
+	return "", errors.New("unsupported or encrypted private key")
+}
+
+/*
+isEncryptedKey reports if the private key file is an encrypted PKCS#8 key, PEM or DER encoded, or a PEM key
+encrypted with the legacy OpenSSL headers.
+*/
+func isEncryptedKey(data []byte) bool {
+	if block, _ := pem.Decode(data); block != nil {
+		return block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED")
+	}
+	var info struct {
+		Algorithm     pkix.AlgorithmIdentifier
+		EncryptedData []byte
+	}
+	rest, err := asn1.Unmarshal(data, &info)
+	return err == nil && len(rest) == 0 && info.Algorithm.Algorithm.Equal(oidPBES2)
+}
+
+// oidPBES2 is the encryption algorithm of PKCS#8 keys encrypted by OpenSSL 1.1 and later.
+var oidPBES2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+
+// fileBaseName is the file name without directory and extension, which pairs certificate and key files.
+func fileBaseName(p string) string {
+	name := path.Base(p)
+	return strings.TrimSuffix(name, path.Ext(name))
+}
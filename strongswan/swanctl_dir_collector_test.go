@@ -0,0 +1,193 @@
+package strongswan
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func TestSwanctlDirCollector_Metrics(t *testing.T) {
+	gwKeyID := "5b2029e569d70e0428bb4d07f6b1600550f7c11b"
+	serverKeyID := "f0ccd31f81afd8d3f57588f0451b417b8ea88df2"
+	fvc := &fakeViciClient{
+		certMsgs: []*vici.Message{
+			caCertMsg("testdata/swanctl/x509ca/ca.pem"),
+			ownCertMsg("testdata/swanctl/x509/gw.pem"),
+			ownCertMsg("testdata/cert.pem"),
+			// received from a peer
+			x509CertMsg("testdata/chain-leaf.pem"),
+		},
+		cmdMsgs: map[string]*vici.Message{"get-keys": credKeysMsg(gwKeyID, serverKeyID)},
+	}
+	tests := []struct {
+		name             string
+		viciClientErr    error
+		dir              string
+		metricName       string
+		wantMetrics      string
+		wantMetricsCount int
+	}{
+		{
+			name:       "files",
+			dir:        "testdata/swanctl",
+			metricName: "swtest_swanctl_files",
+			wantMetrics: `# HELP swtest_swanctl_files Number of files in the credential directory of the swanctl directory
+# TYPE swtest_swanctl_files gauge
+swtest_swanctl_files{dir="ecdsa"} 1
+swtest_swanctl_files{dir="pkcs8"} 1
+swtest_swanctl_files{dir="private"} 1
+swtest_swanctl_files{dir="rsa"} 1
+swtest_swanctl_files{dir="x509"} 2
+swtest_swanctl_files{dir="x509ca"} 1
+swtest_swanctl_files{dir="x509crl"} 1
+`,
+			wantMetricsCount: 23,
+		},
+		{
+			name:       "missing directory",
+			dir:        "testdata/missing",
+			metricName: "swtest_swanctl_files",
+			wantMetrics: `# HELP swtest_swanctl_files Number of files in the credential directory of the swanctl directory
+# TYPE swtest_swanctl_files gauge
+swtest_swanctl_files{dir="ecdsa"} 0
+swtest_swanctl_files{dir="pkcs8"} 0
+swtest_swanctl_files{dir="private"} 0
+swtest_swanctl_files{dir="rsa"} 0
+swtest_swanctl_files{dir="x509"} 0
+swtest_swanctl_files{dir="x509ca"} 0
+swtest_swanctl_files{dir="x509crl"} 0
+`,
+			wantMetricsCount: 13,
+		},
+		{
+			name:          "certificate expiry without vici connection",
+			viciClientErr: errors.New("some error"),
+			dir:           "testdata/swanctl",
+			metricName:    "swtest_swanctl_cert_expire_seconds",
+			wantMetrics: `# HELP swtest_swanctl_cert_expire_seconds Seconds until the X509 certificate file expires
+# TYPE swtest_swanctl_cert_expire_seconds gauge
+swtest_swanctl_cert_expire_seconds{path="x509/gw.pem",serial_number="11",subject="CN=gw.swanctl.example,O=Swanctl,C=CH"} 1.96992e+07
+swtest_swanctl_cert_expire_seconds{path="x509/peer.der",serial_number="12",subject="CN=peer.swanctl.example,O=Swanctl,C=CH"} 1.17504e+07
+swtest_swanctl_cert_expire_seconds{path="x509ca/ca.pem",serial_number="10",subject="CN=Swanctl CA,O=Swanctl,C=CH"} 2.591136e+08
+`,
+			wantMetricsCount: 14,
+		},
+		{
+			name:       "CRL expiry",
+			dir:        "testdata/swanctl",
+			metricName: "swtest_swanctl_crl_expire_seconds",
+			wantMetrics: `# HELP swtest_swanctl_crl_expire_seconds Seconds until the next update of the CRL file
+# TYPE swtest_swanctl_crl_expire_seconds gauge
+swtest_swanctl_crl_expire_seconds{issuer="CN=Swanctl CA,O=Swanctl,C=CH",path="x509crl/ca.crl"} 1.3824e+06
+`,
+			wantMetricsCount: 23,
+		},
+		{
+			name:       "files loaded",
+			dir:        "testdata/swanctl",
+			metricName: "swtest_swanctl_file_loaded",
+			wantMetrics: `# HELP swtest_swanctl_file_loaded Flag if the credential file is loaded into charon
+# TYPE swtest_swanctl_file_loaded gauge
+swtest_swanctl_file_loaded{path="ecdsa/gw.pem",type="key"} 1
+swtest_swanctl_file_loaded{path="private/peer.pem",type="key"} 0
+swtest_swanctl_file_loaded{path="rsa/legacy.pem",type="key"} 0
+swtest_swanctl_file_loaded{path="x509/gw.pem",type="cert"} 1
+swtest_swanctl_file_loaded{path="x509/peer.der",type="cert"} 0
+swtest_swanctl_file_loaded{path="x509ca/ca.pem",type="cert"} 1
+swtest_swanctl_file_loaded{path="x509crl/ca.crl",type="crl"} 0
+`,
+			wantMetricsCount: 23,
+		},
+		{
+			name:       "certificate loaded without file",
+			dir:        "testdata/swanctl",
+			metricName: "swtest_swanctl_cert_loaded_without_file",
+			wantMetrics: `# HELP swtest_swanctl_cert_loaded_without_file Loaded X509 certificate which is not found in the swanctl directory
+# TYPE swtest_swanctl_cert_loaded_without_file gauge
+swtest_swanctl_cert_loaded_without_file{serial_number="76:38:40:b8:25:18:44:0a",subject="CN=server.strongswan.org,O=Cyber,C=CH"} 1
+`,
+			wantMetricsCount: 23,
+		},
+		{
+			name:       "key loaded without file",
+			dir:        "testdata/swanctl",
+			metricName: "swtest_swanctl_key_loaded_without_file",
+			wantMetrics: `# HELP swtest_swanctl_key_loaded_without_file Loaded private key which is not found in the swanctl directory
+# TYPE swtest_swanctl_key_loaded_without_file gauge
+swtest_swanctl_key_loaded_without_file{key_id="f0ccd31f81afd8d3f57588f0451b417b8ea88df2"} 1
+`,
+			wantMetricsCount: 23,
+		},
+		{
+			name:       "encrypted keys",
+			dir:        "testdata/swanctl",
+			metricName: "swtest_swanctl_encrypted_keys",
+			wantMetrics: `# HELP swtest_swanctl_encrypted_keys Number of encrypted private key files, which are not compared with the loaded private keys
+# TYPE swtest_swanctl_encrypted_keys gauge
+swtest_swanctl_encrypted_keys 1
+`,
+			wantMetricsCount: 23,
+		},
+		{
+			name:       "certificate and key pairs",
+			dir:        "testdata/swanctl",
+			metricName: "swtest_swanctl_cert_key_match",
+			wantMetrics: `# HELP swtest_swanctl_cert_key_match Flag if the private key file with the same name as the certificate file belongs to the certificate
+# TYPE swtest_swanctl_cert_key_match gauge
+swtest_swanctl_cert_key_match{cert_path="x509/gw.pem",key_path="ecdsa/gw.pem"} 1
+swtest_swanctl_cert_key_match{cert_path="x509/peer.der",key_path="private/peer.pem"} 0
+`,
+			wantMetricsCount: 23,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSwanctlDirCollector("swtest_", func() (ViciClient, error) {
+				return fvc, tt.viciClientErr
+			}, tt.dir, func() time.Time {
+				return time.Unix(1792108800, 0) // 2026-10-16T00:00:00Z
+			})
+
+			cnt := testutil.CollectAndCount(c)
+			require.Equal(t, tt.wantMetricsCount, cnt, "metrics count")
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.wantMetrics), tt.metricName); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
+
+func TestSwanctlDirCollector_UnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "x509"), 0o700))
+	p := filepath.Join(dir, "x509", "gw.pem")
+	data, err := os.ReadFile("testdata/swanctl/x509/gw.pem")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(p, data, 0o600))
+	info, err := os.Stat(p)
+	require.NoError(t, err)
+
+	c := NewSwanctlDirCollector("swtest_", func() (ViciClient, error) {
+		return nil, errors.New("some error")
+	}, dir, func() time.Time {
+		return time.Unix(1792108800, 0) // 2026-10-16T00:00:00Z
+	})
+	require.Equal(t, 1, testutil.CollectAndCount(c, "swtest_swanctl_cert_expire_seconds"))
+
+	// same modification time and size, the certificate parsed before is reused
+	require.NoError(t, os.WriteFile(p, bytes.Repeat([]byte("x"), len(data)), 0o600))
+	require.NoError(t, os.Chtimes(p, info.ModTime(), info.ModTime()))
+	require.Equal(t, 1, testutil.CollectAndCount(c, "swtest_swanctl_cert_expire_seconds"))
+
+	require.NoError(t, os.Chtimes(p, info.ModTime().Add(time.Second), info.ModTime().Add(time.Second)))
+	require.Equal(t, 0, testutil.CollectAndCount(c, "swtest_swanctl_cert_expire_seconds"))
+}
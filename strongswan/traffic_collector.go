@@ -0,0 +1,263 @@
+package strongswan
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+var trafficCollectorEvents = []string{eventChildUpdown, eventChildRekey}
+
+// finishedTrafficRetention is how long the totals of a CHILD SA are kept after it was last listed or deleted.
+const finishedTrafficRetention = time.Hour
+
+// trafficKey identifies a CHILD SA across rekeys.
+type trafficKey struct {
+	ikeName   string
+	childName string
+	localTS   string
+	remoteTS  string
+}
+
+type trafficCounters struct {
+	bytesIn    int64
+	packetsIn  int64
+	bytesOut   int64
+	packetsOut int64
+}
+
+type liveChildSa struct {
+	key      trafficKey
+	counters trafficCounters
+}
+
+/*
+TrafficCollector exposes the traffic of CHILD SAs as counters which survive rekeys. The traffic of deleted
+CHILD SAs is kept per IKE name, CHILD SA name and traffic selectors, with the final values taken from the
+child-updown and child-rekey events if available or from the last scrape otherwise. The totals of every
+IKE name, CHILD SA name and traffic selectors are dropped once they had no CHILD SA for
+finishedTrafficRetention, e.g. those of a road warrior's virtual IP while other clients stay connected.
+
+With the SA cache the traffic of live CHILD SAs only changes with events and reconciles, so the counters
+increase in steps and rate() over windows shorter than the reconcile interval is wrong.
+*/
+type TrafficCollector struct {
+	viciClientFn viciClientFn
+	cache        *SasCache
+	now          func() time.Time
+
+	mu       sync.Mutex
+	finished map[trafficKey]trafficCounters
+	live     map[string]liveChildSa
+	closed   map[string]bool
+	// keySeen is the last time a CHILD SA with the key was listed or deleted.
+	keySeen map[trafficKey]time.Time
+
+	bytesIn    *prometheus.Desc
+	packetsIn  *prometheus.Desc
+	bytesOut   *prometheus.Desc
+	packetsOut *prometheus.Desc
+}
+
+func NewTrafficCollector(prefix string, viciClientFn viciClientFn, cache *SasCache, now func() time.Time) *TrafficCollector {
+	labels := []string{"ike_name", "child_name", "local_ts", "remote_ts"}
+	return &TrafficCollector{
+		viciClientFn: viciClientFn,
+		cache:        cache,
+		now:          now,
+		finished:     make(map[trafficKey]trafficCounters),
+		live:         make(map[string]liveChildSa),
+		closed:       make(map[string]bool),
+		keySeen:      make(map[trafficKey]time.Time),
+
+		bytesIn: prometheus.NewDesc(
+			prefix+"child_inbound_bytes_total",
+			"Number of input bytes processed by the CHILD SAs including rekeyed and deleted ones",
+			labels, nil,
+		),
+		packetsIn: prometheus.NewDesc(
+			prefix+"child_inbound_packets_total",
+			"Number of input packets processed by the CHILD SAs including rekeyed and deleted ones",
+			labels, nil,
+		),
+		bytesOut: prometheus.NewDesc(
+			prefix+"child_outbound_bytes_total",
+			"Number of output bytes processed by the CHILD SAs including rekeyed and deleted ones",
+			labels, nil,
+		),
+		packetsOut: prometheus.NewDesc(
+			prefix+"child_outbound_packets_total",
+			"Number of output packets processed by the CHILD SAs including rekeyed and deleted ones",
+			labels, nil,
+		),
+	}
+}
+
+func (c *TrafficCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.bytesIn
+	ch <- c.packetsIn
+	ch <- c.bytesOut
+	ch <- c.packetsOut
+}
+
+func (c *TrafficCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, newScrape(c.viciClientFn, c.cache))
+}
+
+func (c *TrafficCollector) collect(ch chan<- prometheus.Metric, s *scrape) {
+	sas, err := s.listSas()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The totals are exported even if listing failed, only the live CHILD SAs are not updated.
+	if err == nil {
+		c.update(sas)
+	}
+
+	totals := make(map[trafficKey]trafficCounters, len(c.finished))
+	for k, v := range c.finished {
+		totals[k] = v
+	}
+	for _, l := range c.live {
+		totals[l.key] = totals[l.key].add(l.counters)
+	}
+
+	for k, v := range totals {
+		labels := []string{k.ikeName, k.childName, k.localTS, k.remoteTS}
+		ch <- prometheus.MustNewConstMetric(c.bytesIn, prometheus.CounterValue, float64(v.bytesIn), labels...)
+		ch <- prometheus.MustNewConstMetric(c.packetsIn, prometheus.CounterValue, float64(v.packetsIn), labels...)
+		ch <- prometheus.MustNewConstMetric(c.bytesOut, prometheus.CounterValue, float64(v.bytesOut), labels...)
+		ch <- prometheus.MustNewConstMetric(c.packetsOut, prometheus.CounterValue, float64(v.packetsOut), labels...)
+	}
+}
+
+// update refreshes the live CHILD SAs and moves the ones which disappeared without an event to the finished totals.
+func (c *TrafficCollector) update(sas []IkeSa) {
+	now := c.now()
+	seen := make(map[string]bool)
+	for _, ikeSa := range sas {
+		for _, child := range ikeSa.Children {
+			seen[child.UniqueID] = true
+			if c.closed[child.UniqueID] {
+				continue
+			}
+			l, ok := c.live[child.UniqueID]
+			if !ok {
+				l.key = newTrafficKey(ikeSa.Name, child)
+			}
+			l.counters = l.counters.max(childTrafficCounters(child))
+			c.live[child.UniqueID] = l
+			c.keySeen[l.key] = now
+		}
+	}
+	for id, l := range c.live {
+		if !seen[id] {
+			c.finished[l.key] = c.finished[l.key].add(l.counters)
+			delete(c.live, id)
+		}
+	}
+	for id := range c.closed {
+		if !seen[id] {
+			delete(c.closed, id)
+		}
+	}
+	for k, t := range c.keySeen {
+		if now.Sub(t) >= finishedTrafficRetention {
+			delete(c.keySeen, k)
+			delete(c.finished, k)
+		}
+	}
+}
+
+func (c *TrafficCollector) handleEvent(e vici.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Name {
+	case eventChildUpdown:
+		if e.Message.Get("up") == "yes" {
+			return
+		}
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			for _, child := range ikeSa.Children {
+				c.finish(ikeSa.Name, child)
+			}
+		}
+	case eventChildRekey:
+		for _, ikeSa := range unmarshalIkeSas(e.Message) {
+			childSas, ok := e.Message.Get(ikeSa.Name).(*vici.Message).Get("child-sas").(*vici.Message)
+			if !ok {
+				continue
+			}
+			for _, name := range childSas.Keys() {
+				oldSa, _, err := unmarshalRekey[ChildIkeSa](childSas, name)
+				if err != nil {
+					log.Logger.Warnf("Message unmarshal error: %v", err)
+					continue
+				}
+				c.finish(ikeSa.Name, oldSa)
+			}
+		}
+	}
+}
+
+// finish adds the final traffic of a deleted or rekeyed CHILD SA to the totals.
+func (c *TrafficCollector) finish(ikeName string, child ChildIkeSa) {
+	if c.closed[child.UniqueID] {
+		return
+	}
+	counters := childTrafficCounters(child)
+	key := newTrafficKey(ikeName, child)
+	if l, ok := c.live[child.UniqueID]; ok {
+		counters = counters.max(l.counters)
+		key = l.key
+		delete(c.live, child.UniqueID)
+	}
+	c.finished[key] = c.finished[key].add(counters)
+	c.closed[child.UniqueID] = true
+	c.keySeen[key] = c.now()
+}
+
+// resync is a no-op, CHILD SAs deleted while the event stream was down are accounted on the next scrape.
+func (c *TrafficCollector) resync() {}
+
+func newTrafficKey(ikeName string, child ChildIkeSa) trafficKey {
+	return trafficKey{
+		ikeName:   ikeName,
+		childName: child.Name,
+		localTS:   strings.Join(child.LocalTS, ";"),
+		remoteTS:  strings.Join(child.RemoteTS, ";"),
+	}
+}
+
+func childTrafficCounters(child ChildIkeSa) trafficCounters {
+	return trafficCounters{
+		bytesIn:    child.BytesIn,
+		packetsIn:  child.PacketsIn,
+		bytesOut:   child.BytesOut,
+		packetsOut: child.PacketsOut,
+	}
+}
+
+func (t trafficCounters) add(o trafficCounters) trafficCounters {
+	return trafficCounters{
+		bytesIn:    t.bytesIn + o.bytesIn,
+		packetsIn:  t.packetsIn + o.packetsIn,
+		bytesOut:   t.bytesOut + o.bytesOut,
+		packetsOut: t.packetsOut + o.packetsOut,
+	}
+}
+
+func (t trafficCounters) max(o trafficCounters) trafficCounters {
+	return trafficCounters{
+		bytesIn:    max(t.bytesIn, o.bytesIn),
+		packetsIn:  max(t.packetsIn, o.packetsIn),
+		bytesOut:   max(t.bytesOut, o.bytesOut),
+		packetsOut: max(t.packetsOut, o.packetsOut),
+	}
+}
@@ -0,0 +1,120 @@
+package strongswan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func trafficChildMsg(uniqueID string, state string, bytesIn int) *vici.Message {
+	m := childSaMsg("net", uniqueID, state)
+	m.Set("bytes-in", bytesIn)
+	m.Set("packets-in", bytesIn/10)
+	m.Set("local-ts", []string{"10.1.0.0/16"})
+	m.Set("remote-ts", []string{"10.2.0.0/16"})
+	return m
+}
+
+func TestTrafficCollector_Metrics(t *testing.T) {
+	now := time.Unix(1000, 0)
+	fvc := &fakeViciClient{}
+	c := NewTrafficCollector("swtest_", func() (ViciClient, error) {
+		return fvc, nil
+	}, nil, func() time.Time {
+		return now
+	})
+	listSas := func(children map[string]*vici.Message) {
+		msg := vici.NewMessage()
+		msg.Set("home", ikeSaMsg("1", "ESTABLISHED", children))
+		fvc.saMsgs = []*vici.Message{msg}
+	}
+	assertBytesIn := func(want int) {
+		t.Helper()
+		wantContent := fmt.Sprintf(`# HELP swtest_child_inbound_bytes_total Number of input bytes processed by the CHILD SAs including rekeyed and deleted ones
+# TYPE swtest_child_inbound_bytes_total counter
+swtest_child_inbound_bytes_total{child_name="net",ike_name="home",local_ts="10.1.0.0/16",remote_ts="10.2.0.0/16"} %d
+`, want)
+		if err := testutil.CollectAndCompare(c, strings.NewReader(wantContent), "swtest_child_inbound_bytes_total"); err != nil {
+			t.Errorf("unexpected collecting result:\n%s", err)
+		}
+	}
+
+	listSas(map[string]*vici.Message{"net-1": trafficChildMsg("1", "INSTALLED", 100)})
+	assertBytesIn(100)
+
+	// Rekey with traffic after the last scrape, the old CHILD SA is still listed until it is deleted.
+	childRekey := ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{"net": rekeyMsg(trafficChildMsg("1", "REKEYED", 150), trafficChildMsg("2", "INSTALLED", 0))})
+	c.handleEvent(vici.Event{Name: eventChildRekey, Message: eventMsg(false, "home", childRekey)})
+	listSas(map[string]*vici.Message{
+		"net-1": trafficChildMsg("1", "REKEYED", 150),
+		"net-2": trafficChildMsg("2", "INSTALLED", 30),
+	})
+	assertBytesIn(180)
+
+	// A CHILD SA which was installed and deleted between two scrapes.
+	c.handleEvent(vici.Event{Name: eventChildUpdown, Message: eventMsg(false, "home", ikeSaMsg("1", "ESTABLISHED", map[string]*vici.Message{"net-3": trafficChildMsg("3", "DELETING", 50)}))})
+	listSas(map[string]*vici.Message{"net-2": trafficChildMsg("2", "INSTALLED", 40)})
+	assertBytesIn(240)
+
+	// A CHILD SA which disappeared without an event keeps the traffic of the last scrape.
+	listSas(nil)
+	assertBytesIn(240)
+
+	// The totals are kept while the CHILD SA is gone for less than the retention.
+	fvc.saMsgs = nil
+	now = now.Add(finishedTrafficRetention - time.Second)
+	assertBytesIn(240)
+	listSas(map[string]*vici.Message{"net-4": trafficChildMsg("4", "INSTALLED", 10)})
+	assertBytesIn(250)
+
+	// The totals are dropped once the CHILD SA is gone for the retention.
+	fvc.saMsgs = nil
+	now = now.Add(finishedTrafficRetention)
+	require.Zero(t, testutil.CollectAndCount(c), "metrics count")
+}
+
+func TestTrafficCollector_RoadWarriors(t *testing.T) {
+	now := time.Unix(1000, 0)
+	fvc := &fakeViciClient{}
+	c := NewTrafficCollector("swtest_", func() (ViciClient, error) {
+		return fvc, nil
+	}, nil, func() time.Time {
+		return now
+	})
+	// The IKE name stays up, while the clients and their virtual IPs change.
+	listClient := func(uniqueID string, virtualIP string) {
+		child := trafficChildMsg(uniqueID, "INSTALLED", 100)
+		child.Set("remote-ts", []string{virtualIP})
+		msg := vici.NewMessage()
+		msg.Set("rw", ikeSaMsg(uniqueID, "ESTABLISHED", map[string]*vici.Message{"net-" + uniqueID: child}))
+		fvc.saMsgs = []*vici.Message{msg}
+	}
+	assertRemoteTSs := func(remoteTSs ...string) {
+		t.Helper()
+		want := `# HELP swtest_child_inbound_bytes_total Number of input bytes processed by the CHILD SAs including rekeyed and deleted ones
+# TYPE swtest_child_inbound_bytes_total counter
+`
+		for _, ts := range remoteTSs {
+			want += fmt.Sprintf("swtest_child_inbound_bytes_total{child_name=\"net\",ike_name=\"rw\",local_ts=\"10.1.0.0/16\",remote_ts=%q} 100\n", ts)
+		}
+		if err := testutil.CollectAndCompare(c, strings.NewReader(want), "swtest_child_inbound_bytes_total"); err != nil {
+			t.Errorf("unexpected collecting result:\n%s", err)
+		}
+	}
+
+	listClient("1", "10.3.0.1/32")
+	assertRemoteTSs("10.3.0.1/32")
+
+	now = now.Add(finishedTrafficRetention / 2)
+	listClient("2", "10.3.0.2/32")
+	assertRemoteTSs("10.3.0.1/32", "10.3.0.2/32")
+
+	// The totals of the first client expire, although the IKE name had an IKE SA all the time.
+	now = now.Add(finishedTrafficRetention / 2)
+	assertRemoteTSs("10.3.0.2/32")
+}
@@ -0,0 +1,151 @@
+package strongswan
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/strongswan/govici/vici"
+	"github.com/torilabs/ipsec-prometheus-exporter/log"
+)
+
+// versionRequirement is the strongSwan version which introduced the Vici commands, events or fields a feature relies on.
+type versionRequirement struct {
+	feature    string
+	minVersion string
+}
+
+/*
+VersionCollector exposes the daemon version of the Vici version command. Whenever a new version is
+detected, it is checked against the requirements of the enabled features and a warning is logged for
+every feature the running daemon does not support.
+*/
+type VersionCollector struct {
+	viciClientFn viciClientFn
+	requirements []versionRequirement
+
+	mu      sync.Mutex
+	checked string
+
+	info *prometheus.Desc
+}
+
+func NewVersionCollector(prefix string, viciClientFn viciClientFn) *VersionCollector {
+	return &VersionCollector{
+		viciClientFn: viciClientFn,
+
+		info: prometheus.NewDesc(
+			prefix+"daemon_info",
+			"Name, version and host system of the daemon",
+			[]string{"daemon", "version", "sysname", "release", "machine"}, nil,
+		),
+	}
+}
+
+// require registers the minimum daemon version of an enabled feature.
+func (c *VersionCollector) require(feature string, minVersion string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requirements = append(c.requirements, versionRequirement{feature: feature, minVersion: minVersion})
+}
+
+func (c *VersionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+}
+
+func (c *VersionCollector) Collect(ch chan<- prometheus.Metric) {
+	v, err := c.version()
+	if err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.info,
+		prometheus.GaugeValue,
+		1,
+		v.Daemon, v.Version, v.Sysname, v.Release, v.Machine,
+	)
+	c.check(v.Version)
+}
+
+// check logs the features which are not supported by the daemon version, once per detected version.
+func (c *VersionCollector) check(version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version == c.checked {
+		return
+	}
+	c.checked = version
+	if len(versionParts(version)) == 0 {
+		log.Logger.Warnf("Unknown strongSwan version format: '%v'", version)
+		return
+	}
+	log.Logger.Infof("Detected strongSwan version %v.", version)
+	for _, r := range c.requirements {
+		if compareVersions(version, r.minVersion) < 0 {
+			log.Logger.Warnf("%v requires strongSwan %v or later, the running daemon is %v.", r.feature, r.minVersion, version)
+		}
+	}
+}
+
+func (c *VersionCollector) version() (Version, error) {
+	var v Version
+	s, err := c.viciClientFn()
+	if err != nil {
+		return v, err
+	}
+	defer s.Close()
+
+	m, err := s.CommandRequest("version", vici.NewMessage())
+	if err != nil {
+		return v, err
+	}
+	if err = vici.UnmarshalMessage(m, &v); err != nil {
+		log.Logger.Warnf("Message unmarshal error: %v", err)
+		return v, err
+	}
+	return v, nil
+}
+
+// compareVersions compares the numeric parts of two dotted versions, suffixes like "dr1" or "rc1" are ignored.
+func compareVersions(a string, b string) int {
+	as, bs := versionParts(a), versionParts(b)
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		end := strings.IndexFunc(p, func(r rune) bool { return r < '0' || r > '9' })
+		if end == 0 {
+			break
+		}
+		if end > 0 {
+			p = p[:end]
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+		if end > 0 {
+			break
+		}
+	}
+	return parts
+}
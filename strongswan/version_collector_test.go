@@ -0,0 +1,56 @@
+package strongswan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/strongswan/govici/vici"
+)
+
+func TestVersionCollector_Metrics(t *testing.T) {
+	m := vici.NewMessage()
+	m.Set("daemon", "charon-systemd")
+	m.Set("version", "5.9.14")
+	m.Set("sysname", "Linux")
+	m.Set("release", "6.8.0-45-generic")
+	m.Set("machine", "x86_64")
+	c := NewVersionCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{cmdMsgs: map[string]*vici.Message{"version": m}}, nil
+	})
+
+	want := `# HELP swtest_daemon_info Name, version and host system of the daemon
+# TYPE swtest_daemon_info gauge
+swtest_daemon_info{daemon="charon-systemd",machine="x86_64",release="6.8.0-45-generic",sysname="Linux",version="5.9.14"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestVersionCollector_Error(t *testing.T) {
+	c := NewVersionCollector("swtest_", func() (ViciClient, error) {
+		return &fakeViciClient{}, nil
+	})
+	require.Equal(t, 0, testutil.CollectAndCount(c), "metrics count")
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "5.9.14", b: "5.9.14", want: 0},
+		{a: "5.9.14", b: "5.10.0", want: -1},
+		{a: "6.0.0", b: "5.9.14", want: 1},
+		{a: "6.0.0dr1", b: "6.0.0", want: 0},
+		{a: "5.6", b: "5.6.1", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			require.Equal(t, tt.want, compareVersions(tt.a, tt.b))
+		})
+	}
+}
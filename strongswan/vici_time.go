@@ -0,0 +1,19 @@
+package strongswan
+
+import (
+	"fmt"
+	"time"
+)
+
+// viciTimeLayouts are the formats of the times printed by charon, in its local time or with the time zone (e.g. UTC).
+var viciTimeLayouts = []string{"Jan 02 15:04:05 2006", "Jan 02 15:04:05 MST 2006"}
+
+// parseViciTime parses a time printed by charon, times without time zone are in the given location.
+func parseViciTime(v string, loc *time.Location) (time.Time, error) {
+	for _, layout := range viciTimeLayouts {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format: '%v'", v)
+}